@@ -17,6 +17,7 @@ import (
 // needing to reconstruct an entirely new tree for every operation.
 type AVLTree[T cmp.Ordered, U any] struct {
 	height int8
+	count  uint64
 	k      T
 	v      U
 	l      *AVLTree[T, U]
@@ -47,18 +48,19 @@ func max[T constraints.Ordered](x, y T) T {
 }
 
 // Size returns the number of elements present in the tree.
+// Size runs in constant time.
 func (t *AVLTree[T, U]) Size() uint64 {
 	if t == nil {
 		return 0
 	}
-	return t.l.Size() + t.r.Size() + 1
+	return t.count
 }
 
 // Insert returns a new tree, consisting of the original tree with the
 // key/value pair `k`/`v` added to it.
 func (t *AVLTree[T, U]) Insert(k T, v U) *AVLTree[T, U] {
 	if t == nil {
-		return &AVLTree[T, U]{k: k, v: v, height: 1}
+		return &AVLTree[T, U]{k: k, v: v, height: 1, count: 1}
 	}
 
 	switch {
@@ -69,8 +71,9 @@ func (t *AVLTree[T, U]) Insert(k T, v U) *AVLTree[T, U] {
 				k:      t.k,
 				v:      t.v,
 				height: t.height,
+				count:  t.count + 1,
 				l:      t.l,
-				r:      &AVLTree[T, U]{k: k, v: v, height: 1},
+				r:      &AVLTree[T, U]{k: k, v: v, height: 1, count: 1},
 			}
 			if t.l == nil {
 				t.height = 2
@@ -92,8 +95,9 @@ func (t *AVLTree[T, U]) Insert(k T, v U) *AVLTree[T, U] {
 				k:      t.k,
 				v:      t.v,
 				height: t.height,
+				count:  t.count + 1,
 				r:      t.r,
-				l:      &AVLTree[T, U]{k: k, v: v, height: 1},
+				l:      &AVLTree[T, U]{k: k, v: v, height: 1, count: 1},
 			}
 			if t.r == nil {
 				t.height = 2
@@ -113,6 +117,7 @@ func (t *AVLTree[T, U]) Insert(k T, v U) *AVLTree[T, U] {
 			k:      k,
 			v:      v,
 			height: t.height,
+			count:  t.count,
 			r:      t.r,
 			l:      t.l,
 		}
@@ -128,6 +133,7 @@ func (t *AVLTree[T, U]) removeLargest() (largest, tree *AVLTree[T, U]) {
 			k:      t.k,
 			v:      t.v,
 			height: t.height,
+			count:  t.count,
 			l:      t.l,
 			r:      t.r,
 		}
@@ -144,12 +150,14 @@ func (t *AVLTree[T, U]) removeLargest() (largest, tree *AVLTree[T, U]) {
 			k:      t.k,
 			v:      t.v,
 			height: t.height,
+			count:  t.count,
 			l:      t.l,
 			r:      t.r,
 		}
 		ret := t.l
 		t.l = nil
 		t.height = 1
+		t.count = 1
 		return t, ret
 	} else {
 		// This is the farthest right node.
@@ -167,34 +175,7 @@ func (t *AVLTree[T, U]) Delete(k T) (*AVLTree[T, U], bool) {
 
 	switch {
 	case t.k == k:
-		if t.l != nil {
-			if t.r != nil {
-				// We have two kids.
-				// Replace the local node with the largest of the
-				// left subtree.
-				t = &AVLTree[T, U]{
-					k:      t.k,
-					v:      t.v,
-					height: t.height,
-					l:      t.l,
-					r:      t.r,
-				}
-				largest, tree := t.l.removeLargest()
-				t.l = tree
-				t.k = largest.k
-				t.v = largest.v
-				t.reheight()
-				return t.balance(), true
-			} else {
-				// We only have a left node. Replace ourselves with  the left node.
-				return t.l, true
-			}
-		} else if t.r != nil {
-			// We only have a right node. Replace ourselves with the right node.
-			return t.r, true
-		} else {
-			return nil, true
-		}
+		return t.deleteRoot(), true
 
 	case t.k < k:
 		if t.r == nil {
@@ -205,6 +186,7 @@ func (t *AVLTree[T, U]) Delete(k T) (*AVLTree[T, U], bool) {
 					k:      t.k,
 					v:      t.v,
 					height: t.height,
+					count:  t.count,
 					l:      t.l,
 					r:      t.r,
 				}
@@ -223,6 +205,7 @@ func (t *AVLTree[T, U]) Delete(k T) (*AVLTree[T, U], bool) {
 					k:      t.k,
 					v:      t.v,
 					height: t.height,
+					count:  t.count,
 					l:      t.l,
 					r:      t.r,
 				}
@@ -236,6 +219,89 @@ func (t *AVLTree[T, U]) Delete(k T) (*AVLTree[T, U], bool) {
 	return t.balance(), true
 }
 
+// Update returns a new tree in which the value for key `k` has been
+// replaced by the result of `f`. `f` is called with the current value of
+// `k` and whether `k` is present. If `f` returns true, its value is
+// inserted or replaces the current one. If it returns false, `k` is
+// removed. Update walks the tree once, and returns a boolean indicating
+// whether or not the tree was changed. If nothing changed, it returns `t`.
+func (t *AVLTree[T, U]) Update(k T, f func(old U, ok bool) (U, bool)) (*AVLTree[T, U], bool) {
+	if t == nil {
+		var zero U
+		v, keep := f(zero, false)
+		if !keep {
+			return nil, false
+		}
+		return &AVLTree[T, U]{k: k, v: v, height: 1, count: 1}, true
+	}
+
+	switch {
+	case t.k == k:
+		v, keep := f(t.v, true)
+		if !keep {
+			return t.deleteRoot(), true
+		}
+		return &AVLTree[T, U]{
+			k:      t.k,
+			v:      v,
+			height: t.height,
+			count:  t.count,
+			l:      t.l,
+			r:      t.r,
+		}, true
+	case t.k < k:
+		tr, ok := t.r.Update(k, f)
+		if !ok {
+			return t, false
+		}
+		t = &AVLTree[T, U]{k: t.k, v: t.v, l: t.l, r: tr}
+		t.reheight()
+		return t.balance(), true
+	case t.k > k:
+		tl, ok := t.l.Update(k, f)
+		if !ok {
+			return t, false
+		}
+		t = &AVLTree[T, U]{k: t.k, v: t.v, l: tl, r: t.r}
+		t.reheight()
+		return t.balance(), true
+	}
+	panic("not possible.")
+}
+
+// deleteRoot returns t with its root node removed.
+func (t *AVLTree[T, U]) deleteRoot() *AVLTree[T, U] {
+	if t.l != nil {
+		if t.r != nil {
+			// We have two kids.
+			// Replace the local node with the largest of the
+			// left subtree.
+			t = &AVLTree[T, U]{
+				k:      t.k,
+				v:      t.v,
+				height: t.height,
+				count:  t.count,
+				l:      t.l,
+				r:      t.r,
+			}
+			largest, tree := t.l.removeLargest()
+			t.l = tree
+			t.k = largest.k
+			t.v = largest.v
+			t.reheight()
+			return t.balance()
+		} else {
+			// We only have a left node. Replace ourselves with  the left node.
+			return t.l
+		}
+	} else if t.r != nil {
+		// We only have a right node. Replace ourselves with the right node.
+		return t.r
+	} else {
+		return nil
+	}
+}
+
 func (t *AVLTree[T, U]) balance() *AVLTree[T, U] {
 	bf := t.bf()
 	switch {
@@ -251,6 +317,7 @@ func (t *AVLTree[T, U]) balance() *AVLTree[T, U] {
 				k:      t.k,
 				v:      t.v,
 				height: t.height,
+				count:  t.count,
 				l:      t.l.left_rotate(),
 				r:      t.r,
 			}
@@ -271,6 +338,7 @@ func (t *AVLTree[T, U]) balance() *AVLTree[T, U] {
 				k:      t.k,
 				v:      t.v,
 				height: t.height,
+				count:  t.count,
 				l:      t.l,
 				r:      t.r.right_rotate(),
 			}
@@ -318,7 +386,9 @@ func (t *AVLTree[T, U]) Get(k T) (U, bool) {
 	panic("Not possible.")
 }
 
+// reheight recomputes the height and size of t from its children.
 func (t *AVLTree[T, U]) reheight() {
+	t.count = t.l.Size() + t.r.Size() + 1
 	if t.l != nil {
 		if t.r != nil {
 			t.height = max(t.l.height, t.r.height) + 1
@@ -345,6 +415,7 @@ func (t *AVLTree[T, U]) right_rotate() *AVLTree[T, U] {
 		k:      t.k,
 		v:      t.v,
 		height: t.height,
+		count:  t.count,
 		l:      t.l,
 		r:      t.r,
 	}
@@ -352,6 +423,7 @@ func (t *AVLTree[T, U]) right_rotate() *AVLTree[T, U] {
 		k:      t.l.k,
 		v:      t.l.v,
 		height: t.l.height,
+		count:  t.l.count,
 		l:      t.l.l,
 		r:      t.l.r,
 	}
@@ -377,6 +449,9 @@ func (t *AVLTree[T, U]) right_rotate() *AVLTree[T, U] {
 		y.height = 1
 	}
 
+	y.count = y.l.Size() + y.r.Size() + 1
+	x.count = x.l.Size() + y.count + 1
+
 	// We rotated right, so x.r != nil
 	if x.l != nil {
 		x.height = max(x.l.height, x.r.height) + 1
@@ -400,6 +475,7 @@ func (t *AVLTree[T, U]) left_rotate() *AVLTree[T, U] {
 		k:      t.k,
 		v:      t.v,
 		height: t.height,
+		count:  t.count,
 		l:      t.l,
 		r:      t.r,
 	}
@@ -407,6 +483,7 @@ func (t *AVLTree[T, U]) left_rotate() *AVLTree[T, U] {
 		k:      t.r.k,
 		v:      t.r.v,
 		height: t.r.height,
+		count:  t.r.count,
 		l:      t.r.l,
 		r:      t.r.r,
 	}
@@ -420,6 +497,9 @@ func (t *AVLTree[T, U]) left_rotate() *AVLTree[T, U] {
 	x.l = a
 	x.r = b
 
+	x.count = x.l.Size() + x.r.Size() + 1
+	y.count = x.count + y.r.Size() + 1
+
 	// We rotated left, so y now definitely has a left.
 	if x.l != nil {
 		if x.r != nil {
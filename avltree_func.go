@@ -0,0 +1,265 @@
+package ion
+
+import (
+	"iter"
+)
+
+// AVLTreeFunc is a tree-based map of keys of type T to values of type U,
+// ordered by a user-supplied comparison function. It supports keys which
+// are not cmp.Ordered, such as structs, or strings compared without regard
+// to case. For cmp.Ordered keys, AVLTree is faster.
+//
+// Like AVLTree, AVLTreeFunc is immutable. The comparison function is
+// stored once, alongside the root of the tree, and is shared by every
+// tree derived from it.
+//
+// The zero value is not usable. Use NewAVLTreeFunc to create an empty tree.
+type AVLTreeFunc[T, U any] struct {
+	cmp  func(a, b T) int
+	root *avlFuncNode[T, U]
+}
+
+// NewAVLTreeFunc returns an empty AVLTreeFunc, ordered by `cmp`. `cmp`
+// must return a negative number if a < b, a positive number if a > b,
+// and 0 if a and b are equal, like cmp.Compare.
+func NewAVLTreeFunc[T, U any](cmp func(a, b T) int) *AVLTreeFunc[T, U] {
+	return &AVLTreeFunc[T, U]{cmp: cmp}
+}
+
+type avlFuncNode[T, U any] struct {
+	height int8
+	count  uint64
+	k      T
+	v      U
+	l      *avlFuncNode[T, U]
+	r      *avlFuncNode[T, U]
+}
+
+func (n *avlFuncNode[T, U]) empty() bool           { return n == nil }
+func (n *avlFuncNode[T, U]) key() T                { return n.k }
+func (n *avlFuncNode[T, U]) value() U              { return n.v }
+func (n *avlFuncNode[T, U]) left() treeNode[T, U]  { return n.l }
+func (n *avlFuncNode[T, U]) right() treeNode[T, U] { return n.r }
+
+func (n *avlFuncNode[T, U]) size() uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.count
+}
+
+func (n *avlFuncNode[T, U]) ht() int8 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *avlFuncNode[T, U]) expose() (*avlFuncNode[T, U], T, U, *avlFuncNode[T, U]) {
+	return n.l, n.k, n.v, n.r
+}
+
+func (*avlFuncNode[T, U]) node(l *avlFuncNode[T, U], k T, v U, r *avlFuncNode[T, U]) *avlFuncNode[T, U] {
+	return &avlFuncNode[T, U]{
+		height: max(l.ht(), r.ht()) + 1,
+		count:  l.size() + r.size() + 1,
+		k:      k,
+		v:      v,
+		l:      l,
+		r:      r,
+	}
+}
+
+func (t *AVLTreeFunc[T, U]) joiner() joiner[T, U, *avlFuncNode[T, U]] {
+	return joiner[T, U, *avlFuncNode[T, U]]{
+		compare: t.cmp,
+		expose:  (*avlFuncNode[T, U]).expose,
+		join:    avlJoin[T, U, *avlFuncNode[T, U]],
+	}
+}
+
+func (t *AVLTreeFunc[T, U]) with(root *avlFuncNode[T, U]) *AVLTreeFunc[T, U] {
+	if root == t.root {
+		return t
+	}
+	return &AVLTreeFunc[T, U]{cmp: t.cmp, root: root}
+}
+
+// Insert returns a new tree, consisting of the original tree with the
+// key/value pair `k`/`v` added to it.
+func (t *AVLTreeFunc[T, U]) Insert(k T, v U) *AVLTreeFunc[T, U] {
+	return t.with(t.joiner().insert(t.root, k, v))
+}
+
+// Delete returns a new tree that does not contain the key `k`, and
+// a boolean indicating whether or not an element was removed.
+func (t *AVLTreeFunc[T, U]) Delete(k T) (*AVLTreeFunc[T, U], bool) {
+	root, ok := t.joiner().delete(t.root, k)
+	return t.with(root), ok
+}
+
+// Get looks up the element in the map associated with `k`.
+// It also returns a boolean indicating whether the value was found.
+func (t *AVLTreeFunc[T, U]) Get(k T) (U, bool) {
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(k, n.k); {
+		case c < 0:
+			n = n.l
+		case c > 0:
+			n = n.r
+		default:
+			return n.v, true
+		}
+	}
+	var v U
+	return v, false
+}
+
+// Size returns the number of elements present in the tree.
+// Size runs in constant time.
+func (t *AVLTreeFunc[T, U]) Size() uint64 {
+	return t.root.size()
+}
+
+// Iterate executes `f` over the key/value pairs in the tree in ascending
+// key order, until `f` returns false.
+func (t *AVLTreeFunc[T, U]) Iterate(f func(T, U) bool) {
+	iterateFrom[T, U](t.root, 0, f)
+}
+
+// Reverse executes `f` over the key/value pairs in the tree in descending
+// key order, until `f` returns false.
+func (t *AVLTreeFunc[T, U]) Reverse(f func(T, U) bool) {
+	reverseNodes[T, U](t.root, f)
+}
+
+// All returns an iterator over the key/value pairs in the tree in
+// ascending key order.
+func (t *AVLTreeFunc[T, U]) All() iter.Seq2[T, U] {
+	return t.Iterate
+}
+
+// Backward returns an iterator over the key/value pairs in the tree in
+// descending key order.
+func (t *AVLTreeFunc[T, U]) Backward() iter.Seq2[T, U] {
+	return t.Reverse
+}
+
+// Keys returns a Seq containing the keys of the tree in ascending order.
+func (t *AVLTreeFunc[T, U]) Keys() Seq[T] {
+	return newTreeSeq[T, U](t.root, keyOf[T, U])
+}
+
+// Values returns a Seq containing the values of the tree, in ascending
+// order of their keys.
+func (t *AVLTreeFunc[T, U]) Values() Seq[U] {
+	return newTreeSeq[T, U](t.root, valueOf[T, U])
+}
+
+// Entries returns a Seq containing the key/value pairs of the tree in
+// ascending key order. Elem on the returned Seq runs in O(log n) time.
+func (t *AVLTreeFunc[T, U]) Entries() Seq[Pair[T, U]] {
+	return newTreeSeq[T, U](t.root, MakePair[T, U])
+}
+
+// Min returns the smallest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (t *AVLTreeFunc[T, U]) Min() (T, U, bool) {
+	return minNode[T, U](t.root)
+}
+
+// Max returns the largest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (t *AVLTreeFunc[T, U]) Max() (T, U, bool) {
+	return maxNode[T, U](t.root)
+}
+
+// Floor returns the largest key in the tree less than or equal to `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTreeFunc[T, U]) Floor(k T) (T, U, bool) {
+	return floor[T, U](t.root, t.cmp, k, true)
+}
+
+// Ceiling returns the smallest key in the tree greater than or equal to
+// `k`, and its value. If there is no such key, it returns false.
+func (t *AVLTreeFunc[T, U]) Ceiling(k T) (T, U, bool) {
+	return ceiling[T, U](t.root, t.cmp, k, true)
+}
+
+// Lower returns the largest key in the tree strictly less than `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTreeFunc[T, U]) Lower(k T) (T, U, bool) {
+	return floor[T, U](t.root, t.cmp, k, false)
+}
+
+// Higher returns the smallest key in the tree strictly greater than `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTreeFunc[T, U]) Higher(k T) (T, U, bool) {
+	return ceiling[T, U](t.root, t.cmp, k, false)
+}
+
+// Range returns a Seq of the key/value pairs in the tree with keys
+// between `lo` and `hi`, in ascending key order. `b` determines whether
+// `lo` and `hi` themselves are included in the range.
+func (t *AVLTreeFunc[T, U]) Range(lo, hi T, b Bounds) Seq[Pair[T, U]] {
+	return treeRange[T, U](t.root, t.cmp, lo, hi, b)
+}
+
+// Rank returns the number of keys in the tree less than `k`.
+// Rank runs in O(log n) time.
+func (t *AVLTreeFunc[T, U]) Rank(k T) uint64 {
+	return rank[T, U](t.root, t.cmp, k, false)
+}
+
+// Select returns the `i`th smallest key in the tree, starting from 0,
+// and its value. If the tree has `i` or fewer keys, it returns false.
+// Select runs in O(log n) time.
+func (t *AVLTreeFunc[T, U]) Select(i uint64) (T, U, bool) {
+	return nth[T, U](t.root, i)
+}
+
+// Union returns a tree containing every key present in either `t` or `o`.
+// Keys present in both trees are given the value returned by `resolve`,
+// which is passed the key and the values from `t` and `o`, in that order.
+// If `resolve` is nil, the values from `t` are kept.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `t`.
+func (t *AVLTreeFunc[T, U]) Union(o *AVLTreeFunc[T, U], resolve func(k T, a, b U) U) *AVLTreeFunc[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return t.with(t.joiner().union(t.root, o.root, resolve))
+}
+
+// Intersection returns a tree containing the keys present in both `t` and
+// `o`, with values given by `resolve`, which is passed the key and the
+// values from `t` and `o`, in that order. If `resolve` is nil, the values
+// from `t` are kept.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `t`.
+func (t *AVLTreeFunc[T, U]) Intersection(o *AVLTreeFunc[T, U], resolve func(k T, a, b U) U) *AVLTreeFunc[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return t.with(t.joiner().intersection(t.root, o.root, resolve))
+}
+
+// Difference returns a tree containing the entries of `t` whose keys are
+// not present in `o`.
+//
+// The trees must be ordered by equivalent comparison functions.
+func (t *AVLTreeFunc[T, U]) Difference(o *AVLTreeFunc[T, U]) *AVLTreeFunc[T, U] {
+	return t.with(t.joiner().difference(t.root, o.root))
+}
+
+// SymmetricDifference returns a tree containing the entries of `t` and `o`
+// whose keys are present in only one of the two trees.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `t`.
+func (t *AVLTreeFunc[T, U]) SymmetricDifference(o *AVLTreeFunc[T, U]) *AVLTreeFunc[T, U] {
+	return t.with(t.joiner().symmetricDifference(t.root, o.root))
+}
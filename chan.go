@@ -0,0 +1,42 @@
+package ion
+
+import (
+	"context"
+)
+
+// FromChan returns a Seq[T] containing the values received from `c`.
+// The Seq ends when `c` is closed.
+//
+// Values are only received from `c` as elements of the Seq are requested.
+// Since receiving from a channel consumes the value, the received values are
+// retained as with StateGen, so the returned Seq is immutable and can be
+// used like any other Seq.
+func FromChan[T any](c <-chan T) Seq[T] {
+	return StateGen(func() (T, bool) {
+		e, ok := <-c
+		return e, ok
+	})
+}
+
+// ToChan returns a channel, with a buffer of size `buf`, on which the
+// elements of `s` are sent. A new goroutine iterates `s`, sending the
+// elements on the channel, and closes the channel when `s` ends or `ctx`
+// is done.
+//
+// If the receiver stops receiving from the channel before it is closed,
+// `ctx` must be cancelled in order to release the goroutine.
+func ToChan[T any](ctx context.Context, s Seq[T], buf int) <-chan T {
+	c := make(chan T, buf)
+	go func() {
+		defer close(c)
+		IterateCtx(ctx, s, func(e T) bool {
+			select {
+			case c <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return c
+}
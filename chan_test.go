@@ -0,0 +1,75 @@
+package ion
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFromChan(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		c := make(chan int)
+		done := make(chan struct{})
+		t.Cleanup(func() { close(done) })
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case c <- i:
+				case <-done:
+					return
+				}
+			}
+		}()
+		testInfSeq(t, FromChan(c))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		c := make(chan int, 100)
+		go func() {
+			defer close(c)
+			for i := 0; i < 10000; i++ {
+				c <- i
+			}
+		}()
+		testFinSeq(t, FromChan(c))
+	})
+}
+
+func TestToChan(t *testing.T) {
+	t.Run("fin", func(t *testing.T) {
+		var n int
+		for e := range ToChan(context.Background(), From[int](0, 1).Take(10000), 10) {
+			if e != n {
+				t.Fatalf("Expected %d, but got %d", n, e)
+			}
+			n++
+		}
+		if n != 10000 {
+			t.Fatalf("Expected 10000 elements, but got %d", n)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := ToChan(ctx, From[int](0, 1), 0)
+		for e := range c {
+			if e == 100 {
+				break
+			}
+		}
+		cancel()
+		// The channel must be closed after cancellation.
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-c:
+				if !ok {
+					return
+				}
+			case <-timeout:
+				t.Fatal("Timed out.")
+			}
+		}
+	})
+	t.Run("roundtrip", func(t *testing.T) {
+		testFinSeq(t, FromChan(ToChan(context.Background(), From[int](0, 1).Take(10000), 0)))
+	})
+}
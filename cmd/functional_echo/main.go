@@ -74,12 +74,10 @@ func listen(ctx context.Context, addr string) ion.Seq[result.Res[net.Conn]] {
 	}
 	log.Printf("Listening on %v", addr)
 
-	go func() {
-		<-ctx.Done()
-		ln.Close()
-	}()
+	// Close the listener when ctx is done, to unblock any pending Accept.
+	context.AfterFunc(ctx, func() { ln.Close() })
 
-	return ion.StateGen(func() (result.Res[net.Conn], bool) {
+	return ion.StateGenCtx(ctx, func(ctx context.Context) (result.Res[net.Conn], bool) {
 		conn, err := ln.Accept()
 		if err != nil {
 			log.Printf("Failed to accept connection.\n")
@@ -0,0 +1,188 @@
+package ion
+
+// flatSeq is the concatenation of the Seqs in s.
+// head, if not nil, is the remainder of a Seq that was partially
+// consumed by a Split, and comes before the elements of s.
+type flatSeq[T any] struct {
+	head  Seq[T]
+	s     Seq[Seq[T]]
+	limit uint64
+}
+
+// each executes fn over each of the inner sequences, starting with head.
+func (f *flatSeq[T]) each(fn func(Seq[T]) bool) {
+	if f.head != nil {
+		if !fn(f.head) {
+			return
+		}
+	}
+	f.s.Iterate(fn)
+}
+
+// lazyLen counts the elements of s, up to at most max. It counts with Lazy
+// without running the thunks, so elements that are computed lazily, such
+// as those of a Map, are not computed.
+func lazyLen[T any](s Seq[T], max uint64) uint64 {
+	var c uint64
+	if max == 0 {
+		return 0
+	}
+	s.Lazy(func(func() T) bool {
+		c++
+		return c < max
+	})
+	return c
+}
+
+func (f *flatSeq[T]) Elem(i uint64) (T, bool) {
+	if f.limit > 0 && i >= f.limit {
+		var ret T
+		return ret, false
+	}
+	var res T
+	var found bool
+	f.each(func(inner Seq[T]) bool {
+		if e, ok := inner.Elem(i); ok {
+			res = e
+			found = true
+			return false
+		}
+		// inner contains <= i elements.
+		i -= lazyLen(inner, i)
+		return true
+	})
+	return res, found
+}
+
+func (f *flatSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	if n == 0 {
+		return (*Vec[T])(nil), f
+	}
+	if f.limit > 0 && n >= f.limit {
+		return f, (*Vec[T])(nil)
+	}
+
+	// Find the inner sequence containing the split point, and
+	// split it there.
+	var (
+		right Seq[T]
+		found bool
+		pos   uint64
+	)
+	rem := n
+	f.each(func(inner Seq[T]) bool {
+		c := lazyLen(inner, rem)
+		if c == rem {
+			_, right = inner.Split(rem)
+			found = true
+			return false
+		}
+		rem -= c
+		pos++
+		return true
+	})
+	if !found {
+		return f, (*Vec[T])(nil)
+	}
+
+	rest := f.s
+	if f.head == nil {
+		_, rest = f.s.Split(pos + 1)
+	} else if pos > 0 {
+		_, rest = f.s.Split(pos)
+	}
+	var lim uint64
+	if f.limit > 0 {
+		lim = f.limit - n
+	}
+	r := &flatSeq[T]{
+		head:  right,
+		s:     rest,
+		limit: lim,
+	}
+	return f.Take(n), r
+}
+
+func (f *flatSeq[T]) Take(n uint64) Seq[T] {
+	if n == 0 {
+		return (*Vec[T])(nil)
+	}
+	lim := n
+	if f.limit > 0 && f.limit < n {
+		lim = f.limit
+	}
+	return &flatSeq[T]{
+		head:  f.head,
+		s:     f.s,
+		limit: lim,
+	}
+}
+
+func (f *flatSeq[T]) Iterate(fn func(T) bool) {
+	var i uint64
+	f.each(func(inner Seq[T]) bool {
+		cont := true
+		inner.Iterate(func(e T) bool {
+			i++
+			if !fn(e) || (f.limit > 0 && i == f.limit) {
+				cont = false
+			}
+			return cont
+		})
+		return cont
+	})
+}
+
+func (f *flatSeq[T]) Lazy(fn func(func() T) bool) {
+	var i uint64
+	f.each(func(inner Seq[T]) bool {
+		cont := true
+		inner.Lazy(func(e func() T) bool {
+			i++
+			if !fn(e) || (f.limit > 0 && i == f.limit) {
+				cont = false
+			}
+			return cont
+		})
+		return cont
+	})
+}
+
+// Concat returns a Seq[T] containing the elements of each of `seqs`, in
+// order. Concat is lazy, so the last of `seqs` may be unbounded, but any
+// Seqs following an unbounded Seq will never be reached.
+func Concat[T any](seqs ...Seq[T]) Seq[T] {
+	return &flatSeq[T]{
+		s: BuildVec(func(add func(Seq[T])) {
+			for _, s := range seqs {
+				add(s)
+			}
+		}),
+	}
+}
+
+// Flatten takes a Seq of Seqs and returns a Seq[T] containing the elements
+// of each of the inner Seqs, in order.
+//
+// Flatten is lazy, so `s` may be unbounded. Inner Seqs are only realized as
+// far as is needed to produce the requested elements. Note that locating an
+// element, or the split point of a Split, requires counting the elements of
+// all the inner Seqs preceding it.
+func Flatten[T any](s Seq[Seq[T]]) Seq[T] {
+	return &flatSeq[T]{
+		s: s,
+	}
+}
+
+// FlatMap takes a Seq[T] `s` and a func `f` producing a Seq[U] for each
+// element of `s`, and returns a Seq[U] which is the concatenation of the
+// results of `f`.
+//
+// FlatMap(s, f) is equivalent to
+//
+//	Flatten(Map(s, f))
+//
+// As with Map, `f` may be called multiple times on a given element.
+func FlatMap[T, U any](s Seq[T], f func(T) Seq[U]) Seq[U] {
+	return Flatten(Map(s, f))
+}
@@ -0,0 +1,111 @@
+package ion
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Concat(
+			From[int](0, 1).Take(10),
+			(*Vec[int])(nil),
+			From[int](10, 1).Take(1),
+			From[int](11, 1),
+		))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		a, b := From[int](0, 1).Take(10000).Split(5000)
+		testFinSeq(t, Concat(a, b))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		var seqs []Seq[int]
+		for i := 0; i < 10000; i += 100 {
+			seqs = append(seqs, From[int](i, 1).Take(100))
+		}
+		testFinSeq(t, Concat(seqs...))
+	})
+	t.Run("fin3", func(t *testing.T) {
+		testFinSeq(t, Concat(From[int](0, 1).Take(10), From[int](10, 1)).Take(10000))
+	})
+	t.Run("fin4", func(t *testing.T) {
+		l, _ := Concat(From[int](0, 1).Take(3), From[int](3, 1)).Split(10000)
+		testFinSeq(t, l)
+	})
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, FlatMap(From[int](0, 7), func(i int) Seq[int] {
+			return From[int](i, 1).Take(7)
+		}))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, FlatMap(From[int](0, 100).Take(100), func(i int) Seq[int] {
+			return From[int](i, 1).Take(100)
+		}))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		// Every other inner sequence is empty.
+		testFinSeq(t, FlatMap(From[int](0, 50).Take(400), func(i int) Seq[int] {
+			if i%100 != 0 {
+				return (*Vec[int])(nil)
+			}
+			return From[int](i/2, 1).Take(50)
+		}))
+	})
+}
+
+func TestFlattenLazy(t *testing.T) {
+	var realized int
+	s := Flatten(Map(From[int](0, 1), func(i int) Seq[int] {
+		return Map(Repeatedly(i), func(e int) int {
+			realized++
+			return e
+		})
+	}))
+
+	// Every inner sequence is unbounded, so we should never leave the first.
+	// Finding the split point only counts elements, without realizing them.
+	l, r := s.Split(10)
+	if realized != 0 {
+		t.Fatalf("Expected no elements to be realized, but %d were", realized)
+	}
+	realized = 0
+	if e, ok := r.Elem(1000); !ok || e != 0 {
+		t.Fatalf("Expected r[1000] == 0, but was %d", e)
+	}
+	if l := len(ToSlice(l)); l != 10 {
+		t.Fatalf("Expected 10 elements, but got %d", l)
+	}
+	if realized != 11 {
+		t.Fatalf("Expected 11 elements to be realized, but %d were", realized)
+	}
+}
+
+func TestFlattenCountsLazily(t *testing.T) {
+	var calls atomic.Int64
+	inner := func(start int) Seq[int] {
+		return Map(From[int](start, 1).Take(10), func(i int) int {
+			calls.Add(1)
+			return i
+		})
+	}
+	s := Concat(inner(0), inner(10), inner(20), inner(30))
+
+	// Finding the split point should not compute any elements.
+	_, r := s.Split(25)
+	if c := calls.Load(); c != 0 {
+		t.Fatalf("Expected Split to compute no elements, but it computed %d", c)
+	}
+	if e, ok := r.Elem(0); !ok || e != 25 {
+		t.Fatalf("Expected r[0] == 25, but got %d", e)
+	}
+	calls.Store(0)
+	if e, ok := s.Elem(37); !ok || e != 37 {
+		t.Fatalf("Expected s[37] == 37, but got %d", e)
+	}
+	if c := calls.Load(); c != 1 {
+		t.Fatalf("Expected Elem to compute 1 element, but it computed %d", c)
+	}
+}
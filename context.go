@@ -0,0 +1,129 @@
+package ion
+
+import (
+	"context"
+)
+
+type ctxSeq[T any] struct {
+	ctx context.Context
+	s   Seq[T]
+}
+
+func (c *ctxSeq[T]) Elem(i uint64) (T, bool) {
+	if c.ctx.Err() != nil {
+		var ret T
+		return ret, false
+	}
+	return c.s.Elem(i)
+}
+
+func (c *ctxSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	if c.ctx.Err() != nil {
+		return (*Vec[T])(nil), (*Vec[T])(nil)
+	}
+	sl, sr := c.s.Split(n)
+	l := &ctxSeq[T]{
+		ctx: c.ctx,
+		s:   sl,
+	}
+	r := &ctxSeq[T]{
+		ctx: c.ctx,
+		s:   sr,
+	}
+	return l, r
+}
+
+func (c *ctxSeq[T]) Take(n uint64) Seq[T] {
+	return &ctxSeq[T]{
+		ctx: c.ctx,
+		s:   c.s.Take(n),
+	}
+}
+
+func (c *ctxSeq[T]) Iterate(f func(T) bool) {
+	if c.ctx.Err() != nil {
+		return
+	}
+	c.s.Iterate(func(e T) bool {
+		return c.ctx.Err() == nil && f(e)
+	})
+}
+
+func (c *ctxSeq[T]) Lazy(f func(func() T) bool) {
+	if c.ctx.Err() != nil {
+		return
+	}
+	c.s.Lazy(func(e func() T) bool {
+		return c.ctx.Err() == nil && f(e)
+	})
+}
+
+// WithContext returns a Seq[T] containing the elements of `s`, which ends
+// once `ctx` is done. After `ctx` is done, Elem returns no values, and
+// Iterate and Lazy stop before the next element.
+//
+// Note that WithContext cannot interrupt the computation of an element of
+// `s` which is already in progress. Sequences which block while producing
+// elements, such as those reading from the network, should be constructed
+// with StateGenCtx, or otherwise watch `ctx` themselves.
+func WithContext[T any](ctx context.Context, s Seq[T]) Seq[T] {
+	return &ctxSeq[T]{
+		ctx: ctx,
+		s:   s,
+	}
+}
+
+// IterateCtx is like s.Iterate(f), but stops once `ctx` is done.
+// It returns ctx.Err() if the iteration was stopped because `ctx`
+// was done, and nil otherwise.
+func IterateCtx[T any](ctx context.Context, s Seq[T], f func(T) bool) error {
+	var err error
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	s.Iterate(func(e T) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		return f(e)
+	})
+	return err
+}
+
+// FoldCtx is like Fold, but stops once `ctx` is done. It returns the
+// accumulator value, along with ctx.Err() if the fold was stopped because
+// `ctx` was done. In that case, the accumulator contains the result of
+// folding the elements up to that point.
+//
+// Unlike Fold, FoldCtx can be used on unbounded sequences, running until
+// `ctx` is done.
+func FoldCtx[T, U any](ctx context.Context, s Seq[T], f func(U, T) U) (U, error) {
+	var u U
+	err := IterateCtx(ctx, s, func(e T) bool {
+		u = f(u, e)
+		return true
+	})
+	return u, err
+}
+
+// StateGenCtx is like StateGen, but `f` is passed `ctx`, so that it can
+// stop waiting on I/O or other operations when `ctx` is done. Once `ctx`
+// is done, `f` is no longer called and the sequence ends.
+//
+// For example, a sequence of connections accepted on a listener:
+//
+//	ln, _ := net.Listen("tcp", addr)
+//	context.AfterFunc(ctx, func() { ln.Close() })
+//	conns := StateGenCtx(ctx, func(ctx context.Context) (net.Conn, bool) {
+//		conn, err := ln.Accept()
+//		return conn, err == nil
+//	})
+func StateGenCtx[T any](ctx context.Context, f func(context.Context) (T, bool)) Seq[T] {
+	return StateGen(func() (T, bool) {
+		if ctx.Err() != nil {
+			var ret T
+			return ret, false
+		}
+		return f(ctx)
+	})
+}
@@ -0,0 +1,101 @@
+package ion
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, WithContext(context.Background(), From[int](0, 1)))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, WithContext(context.Background(), From[int](0, 1).Take(10000)))
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		s := WithContext(ctx, From[int](0, 1))
+		l, r := s.Split(10)
+
+		var n int
+		s.Iterate(func(e int) bool {
+			n++
+			if n == 100 {
+				cancel()
+			}
+			return true
+		})
+		if n != 100 {
+			t.Fatalf("Expected iteration to stop after 100 elements, but got %d", n)
+		}
+		if e, ok := s.Elem(0); ok {
+			t.Fatalf("Expected s[0] to return no value, but got %d", e)
+		}
+		if e, ok := r.Elem(0); ok {
+			t.Fatalf("Expected r[0] to return no value, but got %d", e)
+		}
+		if c := len(ToSlice(l)); c != 0 {
+			t.Fatalf("Expected l to be empty, but got %d elements", c)
+		}
+	})
+}
+
+func TestFoldCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sum, err := FoldCtx(ctx, From[int](0, 1).Take(100), func(acc, i int) int {
+		return acc + i
+	})
+	if err != nil || sum != 4950 {
+		t.Fatalf("Expected 4950, nil, but got %d, %v", sum, err)
+	}
+
+	sum, err = FoldCtx(ctx, From[int](0, 1), func(acc, i int) int {
+		if i == 99 {
+			cancel()
+		}
+		return acc + i
+	})
+	if !errors.Is(err, context.Canceled) || sum != 4950 {
+		t.Fatalf("Expected 4950, context.Canceled, but got %d, %v", sum, err)
+	}
+
+	err = IterateCtx(ctx, From[int](0, 1), func(int) bool {
+		t.Fatalf("Expected no elements after cancellation")
+		return false
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, but got %v", err)
+	}
+}
+
+func TestStateGenCtx(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		var i int
+		testInfSeq(t, StateGenCtx(context.Background(), func(context.Context) (int, bool) {
+			ret := i
+			i++
+			return ret, true
+		}))
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var i int
+		s := StateGenCtx(ctx, func(ctx context.Context) (int, bool) {
+			if i == 50 {
+				cancel()
+			}
+			ret := i
+			i++
+			return ret, true
+		})
+		if c := len(ToSlice(s)); c != 51 {
+			t.Fatalf("Expected 51 elements, but got %d", c)
+		}
+		if i != 51 {
+			t.Fatalf("Expected the generator to be called 51 times, but was called %d times", i)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+package ion
+
+import (
+	"cmp"
+	"iter"
+	"runtime"
+	"slices"
+)
+
+// A Cursor is a pull-based iterator over a sequence of elements of type T.
+// Unlike Seqs, Cursors are stateful. Each call to Next advances the Cursor
+// to the next element.
+//
+// Cursors are useful when the consumer needs to control the pace of
+// iteration, for instance when merging multiple sequences, or pausing
+// iteration across function calls.
+//
+// Cursors are not safe for concurrent use.
+type Cursor[T any] interface {
+	// Next returns the next element, advancing the Cursor.
+	// If there are no more elements, it returns the zero value
+	// of T and false.
+	Next() (T, bool)
+
+	// Peek returns the next element without advancing the Cursor.
+	// If there are no more elements, it returns the zero value
+	// of T and false.
+	Peek() (T, bool)
+
+	// Rest returns a Seq containing the elements which have not yet
+	// been returned by Next. The Seq is not affected by further calls
+	// to Next, or by Stop.
+	Rest() Seq[T]
+
+	// Stop releases the resources held by the Cursor, such as the
+	// goroutine a Cursor from NewCursor uses to pull elements from its
+	// Seq. After Stop, Next and Peek return false. Stop may be called
+	// more than once.
+	Stop()
+}
+
+type seqCursor[T any] struct {
+	s Seq[T]
+	// pos is the number of elements returned by Next.
+	pos uint64
+	// next and stop pull elements from s.Iterate, once the first
+	// element is requested.
+	next    func() (T, bool)
+	stop    func()
+	stopped bool
+	peeked  bool
+	e       T
+	ok      bool
+}
+
+// NewCursor returns a Cursor over the elements of `s`.
+//
+// The Cursor pulls elements from s.Iterate, so each element of `s` is
+// computed once, and walking the Cursor to the end takes as long as
+// iterating `s`. Until the Cursor reaches the end of `s`, the iteration is
+// paused in another goroutine, which holds on to `s`. Call Stop when done
+// with the Cursor to end it. Otherwise, it is only ended when the Cursor
+// is garbage collected.
+func NewCursor[T any](s Seq[T]) Cursor[T] {
+	if v, ok := s.(*Vec[T]); ok {
+		return v.Cursor()
+	}
+	return &seqCursor[T]{
+		s: s,
+	}
+}
+
+func (c *seqCursor[T]) Peek() (T, bool) {
+	if c.stopped {
+		var ret T
+		return ret, false
+	}
+	if !c.peeked {
+		if c.next == nil {
+			c.next, c.stop = iter.Pull(iter.Seq[T](c.s.Iterate))
+			// The iteration is paused until the next call to
+			// c.next, so stop it if the Cursor is abandoned
+			// without a call to Stop.
+			runtime.SetFinalizer(c, func(c *seqCursor[T]) { c.stop() })
+		}
+		c.e, c.ok = c.next()
+		c.peeked = true
+	}
+	return c.e, c.ok
+}
+
+func (c *seqCursor[T]) Next() (T, bool) {
+	e, ok := c.Peek()
+	if !ok {
+		return e, false
+	}
+	c.pos++
+	c.peeked = false
+	var zero T
+	c.e = zero
+	return e, true
+}
+
+func (c *seqCursor[T]) Rest() Seq[T] {
+	return Drop(c.s, c.pos)
+}
+
+func (c *seqCursor[T]) Stop() {
+	if c.stop != nil && !c.stopped {
+		c.stop()
+		runtime.SetFinalizer(c, nil)
+	}
+	c.stopped = true
+	var zero T
+	c.e = zero
+}
+
+type vecCursor[T any] struct {
+	v       *Vec[T]
+	pos     uint64
+	leaf    []T
+	stack   []interface{} // *Vec | *seqLeaf
+	stopped bool
+}
+
+// Cursor returns a Cursor over the elements of the Vec.
+// The Cursor walks the leaves of the Vec directly, so Next
+// runs in amortized constant time.
+func (s *Vec[T]) Cursor() Cursor[T] {
+	c := &vecCursor[T]{
+		v: s,
+	}
+	if s != nil {
+		c.stack = append(c.stack, s)
+	}
+	return c
+}
+
+// fill makes sure c.leaf is not empty, unless there are
+// no more elements.
+func (c *vecCursor[T]) fill() bool {
+	if c.stopped {
+		return false
+	}
+	for len(c.leaf) == 0 {
+		if len(c.stack) == 0 {
+			return false
+		}
+		n := c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+		for n != nil {
+			switch o := n.(type) {
+			case *Vec[T]:
+				if o.r != nil {
+					c.stack = append(c.stack, o.r)
+				}
+				n = o.l
+			case *seqLeaf[T]:
+				c.leaf = o.seq
+				n = nil
+			default:
+				panic("Bad Type")
+			}
+		}
+	}
+	return true
+}
+
+func (c *vecCursor[T]) Peek() (T, bool) {
+	if !c.fill() {
+		var ret T
+		return ret, false
+	}
+	return c.leaf[0], true
+}
+
+func (c *vecCursor[T]) Next() (T, bool) {
+	if !c.fill() {
+		var ret T
+		return ret, false
+	}
+	e := c.leaf[0]
+	c.leaf = c.leaf[1:]
+	c.pos++
+	return e, true
+}
+
+func (c *vecCursor[T]) Rest() Seq[T] {
+	return c.v.drop(c.pos)
+}
+
+func (c *vecCursor[T]) Stop() {
+	c.stopped = true
+}
+
+type avlCursor[T cmp.Ordered, U any] struct {
+	stack   []*AVLTree[T, U]
+	stopped bool
+}
+
+// Cursor returns a Cursor over the key/value pairs of the tree,
+// in ascending key order.
+func (t *AVLTree[T, U]) Cursor() Cursor[Pair[T, U]] {
+	c := &avlCursor[T, U]{}
+	c.pushLeft(t)
+	return c
+}
+
+func (c *avlCursor[T, U]) pushLeft(t *AVLTree[T, U]) {
+	for ; t != nil; t = t.l {
+		c.stack = append(c.stack, t)
+	}
+}
+
+func (c *avlCursor[T, U]) Peek() (Pair[T, U], bool) {
+	if c.stopped || len(c.stack) == 0 {
+		return Pair[T, U]{}, false
+	}
+	t := c.stack[len(c.stack)-1]
+	return Pair[T, U]{First: t.k, Second: t.v}, true
+}
+
+func (c *avlCursor[T, U]) Next() (Pair[T, U], bool) {
+	if c.stopped || len(c.stack) == 0 {
+		return Pair[T, U]{}, false
+	}
+	t := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	c.pushLeft(t.r)
+	return Pair[T, U]{First: t.k, Second: t.v}, true
+}
+
+func (c *avlCursor[T, U]) Rest() Seq[Pair[T, U]] {
+	cp := &avlCursor[T, U]{
+		stack: slices.Clone(c.stack),
+	}
+	return StateGen(cp.Next)
+}
+
+func (c *avlCursor[T, U]) Stop() {
+	c.stopped = true
+}
+
+type rbCursor[T cmp.Ordered, U any] struct {
+	stack   []*RBTree[T, U]
+	stopped bool
+}
+
+// Cursor returns a Cursor over the key/value pairs of the tree,
+// in ascending key order.
+func (r *RBTree[T, U]) Cursor() Cursor[Pair[T, U]] {
+	c := &rbCursor[T, U]{}
+	c.pushLeft(r)
+	return c
+}
+
+func (c *rbCursor[T, U]) pushLeft(r *RBTree[T, U]) {
+	for ; r != nil; r = r.l {
+		c.stack = append(c.stack, r)
+	}
+}
+
+func (c *rbCursor[T, U]) Peek() (Pair[T, U], bool) {
+	if c.stopped || len(c.stack) == 0 {
+		return Pair[T, U]{}, false
+	}
+	r := c.stack[len(c.stack)-1]
+	return Pair[T, U]{First: r.k, Second: r.v}, true
+}
+
+func (c *rbCursor[T, U]) Next() (Pair[T, U], bool) {
+	if c.stopped || len(c.stack) == 0 {
+		return Pair[T, U]{}, false
+	}
+	r := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	c.pushLeft(r.r)
+	return Pair[T, U]{First: r.k, Second: r.v}, true
+}
+
+func (c *rbCursor[T, U]) Rest() Seq[Pair[T, U]] {
+	cp := &rbCursor[T, U]{
+		stack: slices.Clone(c.stack),
+	}
+	return StateGen(cp.Next)
+}
+
+func (c *rbCursor[T, U]) Stop() {
+	c.stopped = true
+}
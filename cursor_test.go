@@ -0,0 +1,226 @@
+package ion
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// merge merges two ascending cursors into an ascending slice.
+func merge(a, b Cursor[int]) []int {
+	var res []int
+	for {
+		ea, oka := a.Peek()
+		eb, okb := b.Peek()
+		switch {
+		case !oka && !okb:
+			return res
+		case !okb || (oka && ea <= eb):
+			a.Next()
+			res = append(res, ea)
+		default:
+			b.Next()
+			res = append(res, eb)
+		}
+	}
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		evens := NewCursor(From[int](0, 2).Take(5000))
+		odds := NewCursor(Filter(From[int](0, 1), func(i int) bool { return i%2 == 1 }).Take(5000))
+		testFinSeq(t, BuildVec(func(add func(int)) {
+			for _, e := range merge(evens, odds) {
+				add(e)
+			}
+		}))
+	})
+	t.Run("rest", func(t *testing.T) {
+		c := NewCursor(From[int](0, 1))
+		for i := 0; i < 100; i++ {
+			if e, ok := c.Next(); !ok || e != i {
+				t.Fatalf("Expected %d, but got %d", i, e)
+			}
+		}
+		r := c.Rest()
+		if e, ok := c.Peek(); !ok || e != 100 {
+			t.Fatalf("Expected 100, but got %d", e)
+		}
+		c.Next()
+		testInfSeq(t, Map(r, func(i int) int { return i - 100 }))
+	})
+	t.Run("once", func(t *testing.T) {
+		// Walking the Cursor should compute each element once, and not
+		// re-run the filter from the start for every step.
+		var mapped, filtered int
+		s := Map(Filter(From[int](0, 1), func(i int) bool {
+			filtered++
+			return i%3 == 0
+		}), func(i int) int {
+			mapped++
+			return i / 3
+		}).Take(10000)
+		c := NewCursor(s)
+		for i := 0; i < 10000; i++ {
+			if e, ok := c.Next(); !ok || e != i {
+				t.Fatalf("Expected %d, but got %d", i, e)
+			}
+			c.Peek()
+		}
+		if _, ok := c.Next(); ok {
+			t.Fatalf("Expected no more elements")
+		}
+		if mapped != 10000 || filtered > 30000 {
+			t.Fatalf("Expected 10000 mapped and at most 30000 filtered, but got %d and %d", mapped, filtered)
+		}
+	})
+	t.Run("end", func(t *testing.T) {
+		c := NewCursor(From[int](0, 1).Take(1))
+		c.Next()
+		if e, ok := c.Next(); ok {
+			t.Fatalf("Expected no element, but got %d", e)
+		}
+		if e, ok := c.Peek(); ok {
+			t.Fatalf("Expected no element, but got %d", e)
+		}
+	})
+}
+
+func TestCursorStop(t *testing.T) {
+	t.Run("seq", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		var cs []Cursor[int]
+		for i := 0; i < 10; i++ {
+			c := NewCursor(Map(From[int](0, 1), func(i int) int { return i }))
+			c.Next()
+			cs = append(cs, c)
+		}
+		if n := runtime.NumGoroutine(); n < before+10 {
+			t.Fatalf("Expected each Cursor to hold a goroutine, but there are %d, up from %d", n, before)
+		}
+		for _, c := range cs {
+			c.Stop()
+			c.Stop()
+		}
+		// The goroutines should be released without waiting for the
+		// Cursors to be garbage collected.
+		deadline := time.Now().Add(5 * time.Second)
+		for runtime.NumGoroutine() > before {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected Stop to release the goroutines, but there are %d, up from %d", runtime.NumGoroutine(), before)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		c := cs[0]
+		if e, ok := c.Next(); ok {
+			t.Fatalf("Expected no element after Stop, but got %d", e)
+		}
+		if e, ok := c.Rest().Elem(0); !ok || e != 1 {
+			t.Fatalf("Expected Rest() to start with 1, but got %d", e)
+		}
+		runtime.KeepAlive(cs)
+	})
+	t.Run("others", func(t *testing.T) {
+		v := BuildVec(func(add func(int)) {
+			for i := 0; i < 10; i++ {
+				add(i)
+			}
+		})
+		avl := (*AVLTree[int, int])(nil).Insert(1, 1).Insert(2, 2)
+		rb := (*RBTree[int, int])(nil).Insert(1, 1).Insert(2, 2)
+		vc, ac, rc := v.Cursor(), avl.Cursor(), rb.Cursor()
+		vc.Next()
+		ac.Next()
+		rc.Next()
+		vc.Stop()
+		ac.Stop()
+		rc.Stop()
+		if _, ok := vc.Peek(); ok {
+			t.Fatalf("Expected no element after Stop")
+		}
+		if _, ok := ac.Next(); ok {
+			t.Fatalf("Expected no element after Stop")
+		}
+		if _, ok := rc.Peek(); ok {
+			t.Fatalf("Expected no element after Stop")
+		}
+		if n := len(ToSlice(vc.Rest())); n != 9 {
+			t.Fatalf("Expected Rest() to contain 9 elements, but had %d", n)
+		}
+		if p, ok := rc.Rest().Elem(0); !ok || p.First != 2 {
+			t.Fatalf("Expected Rest() to start with 2, but got %v", p)
+		}
+	})
+}
+
+func TestVecCursor(t *testing.T) {
+	v := BuildVec(func(add func(int)) {
+		for i := 0; i < 10000; i++ {
+			add(i)
+		}
+	})
+	// Make the structure less regular.
+	l, r := v.split(4321)
+	v = l.Join(r)
+
+	c := v.Cursor()
+	for i := 0; i < 10000; i++ {
+		if e, ok := c.Peek(); !ok || e != i {
+			t.Fatalf("Expected Peek() == %d, but got %d", i, e)
+		}
+		if i == 5000 {
+			testFinSeq(t, Concat(From[int](0, 1).Take(5000), c.Rest()))
+		}
+		if e, ok := c.Next(); !ok || e != i {
+			t.Fatalf("Expected Next() == %d, but got %d", i, e)
+		}
+	}
+	if e, ok := c.Next(); ok {
+		t.Fatalf("Expected no element, but got %d", e)
+	}
+	if l := c.Rest().Take(1); len(ToSlice(l)) != 0 {
+		t.Fatalf("Expected Rest() to be empty")
+	}
+
+	if _, ok := (*Vec[int])(nil).Cursor().Next(); ok {
+		t.Fatalf("Expected no element in an empty Vec")
+	}
+}
+
+func TestTreeCursor(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for i := 999; i >= 0; i-- {
+		avl = avl.Insert(i*2, i)
+		rb = rb.Insert(i*2, i)
+	}
+
+	check := func(t *testing.T, c Cursor[Pair[int, int]]) {
+		for i := 0; i < 1000; i++ {
+			if p, ok := c.Peek(); !ok || p.First != i*2 || p.Second != i {
+				t.Fatalf("Expected Peek() == {%d %d}, but got %v", i*2, i, p)
+			}
+			if i == 500 {
+				r := c.Rest()
+				if p, ok := r.Elem(499); !ok || p.First != 1998 {
+					t.Fatalf("Expected Rest()[499] == {1998 999}, but got %v", p)
+				}
+				if n := len(ToSlice(r)); n != 500 {
+					t.Fatalf("Expected Rest() to contain 500 elements, but had %d", n)
+				}
+			}
+			if p, ok := c.Next(); !ok || p.First != i*2 || p.Second != i {
+				t.Fatalf("Expected Next() == {%d %d}, but got %v", i*2, i, p)
+			}
+		}
+		if p, ok := c.Next(); ok {
+			t.Fatalf("Expected no element, but got %v", p)
+		}
+	}
+	t.Run("avl", func(t *testing.T) {
+		check(t, avl.Cursor())
+	})
+	t.Run("rb", func(t *testing.T) {
+		check(t, rb.Cursor())
+	})
+}
@@ -1,5 +1,5 @@
 module github.com/knusbaum/ion
 
-go 1.21.3
+go 1.23
 
 require golang.org/x/exp v0.0.0-20231110203233-9a3e6036ecaa
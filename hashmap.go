@@ -0,0 +1,649 @@
+package ion
+
+import (
+	"hash/maphash"
+	"iter"
+	"math"
+	"math/bits"
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// HashMap is a hash-based map of keys of type K to values of type V,
+// implemented as a hash array mapped trie (HAMT).
+//
+// HashMap is immutable, meaning operations performed on it return
+// new maps without modifying the old. Like the trees, the new map shares
+// most of its memory with the original. Unlike the trees, HashMap does
+// not require ordered keys, and lookups take near-constant time: each
+// level of the trie consumes 5 bits of the key's hash, so a map with
+// n keys is about log32(n) levels deep.
+//
+// The zero value is not usable. Use NewHashMap, or NewHashMapFunc for
+// keys which are not comparable.
+type HashMap[K, V any] struct {
+	hash  func(K) uint64
+	equal func(a, b K) bool
+	root  *hamtNode[K, V]
+	size  uint64
+}
+
+// NewHashMap returns an empty HashMap for comparable keys.
+func NewHashMap[K comparable, V any]() *HashMap[K, V] {
+	return &HashMap[K, V]{
+		hash: comparableHash[K](maphash.MakeSeed()),
+		equal: func(a, b K) bool {
+			return a == b
+		},
+	}
+}
+
+// NewHashMapFunc returns an empty HashMap which hashes keys with `hash`
+// and compares them with `equal`. This allows keys which are not
+// comparable, or which need a custom notion of equality. Keys which are
+// equal must have the same hash.
+func NewHashMapFunc[K, V any](hash func(K) uint64, equal func(a, b K) bool) *HashMap[K, V] {
+	return &HashMap[K, V]{
+		hash:  hash,
+		equal: equal,
+	}
+}
+
+// BuildHashMap builds a HashMap of comparable keys. It calls `f`, which
+// should call `add` for each key/value pair to be added to the map.
+// For example:
+//
+//	BuildHashMap(func(add func(string, int)) {
+//		for i := 0; i < 100; i++ {
+//			add(strconv.Itoa(i), i)
+//		}
+//	})
+//
+// This is more efficient than simply calling Assoc in a loop.
+func BuildHashMap[K comparable, V any](f func(add func(K, V))) *HashMap[K, V] {
+	t := NewHashMap[K, V]().Transient()
+	f(t.Assoc)
+	return t.Persistent()
+}
+
+// A hamtNode is either a bitmap node or a collision node.
+//
+// A bitmap node has one entry for each bit set in bitmap, in order.
+// Each entry is either a key/value pair, or a child node.
+//
+// A collision node has a bitmap of 0, and holds two or more key/value
+// pairs whose keys have the same hash.
+type hamtNode[K, V any] struct {
+	bitmap  uint32
+	entries []hamtEntry[K, V]
+	// edit is the owner of the node while it is being built by a
+	// TransientHashMap. Nodes owned by a transient may be modified in
+	// place by it.
+	edit *hamtOwner
+}
+
+type hamtEntry[K, V any] struct {
+	hash  uint64
+	k     K
+	v     V
+	child *hamtNode[K, V]
+}
+
+// hamtOwner identifies a TransientHashMap. It is not zero-sized, so that
+// each one has a distinct address.
+type hamtOwner struct {
+	_ byte
+}
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+func hamtBit(hash uint64, shift uint) uint32 {
+	return 1 << ((hash >> shift) & hamtMask)
+}
+
+func (n *hamtNode[K, V]) index(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// hamtOp holds what operations on the trie need, besides the trie.
+type hamtOp[K, V any] struct {
+	equal func(a, b K) bool
+	edit  *hamtOwner
+}
+
+func (op hamtOp[K, V]) newNode(bitmap uint32, entries ...hamtEntry[K, V]) *hamtNode[K, V] {
+	return &hamtNode[K, V]{bitmap: bitmap, entries: entries, edit: op.edit}
+}
+
+// editable returns n if it can be modified in place, or a copy of n
+// that can be.
+func (op hamtOp[K, V]) editable(n *hamtNode[K, V]) *hamtNode[K, V] {
+	if op.edit != nil && n.edit == op.edit {
+		return n
+	}
+	return &hamtNode[K, V]{
+		bitmap:  n.bitmap,
+		entries: slices.Clone(n.entries),
+		edit:    op.edit,
+	}
+}
+
+// merge returns a node containing the two leaf entries a and b, which
+// collide at the level above shift.
+func (op hamtOp[K, V]) merge(shift uint, a, b hamtEntry[K, V]) *hamtNode[K, V] {
+	if a.hash == b.hash {
+		return op.newNode(0, a, b)
+	}
+	ba, bb := hamtBit(a.hash, shift), hamtBit(b.hash, shift)
+	switch {
+	case ba == bb:
+		return op.newNode(ba, hamtEntry[K, V]{hash: a.hash, child: op.merge(shift+hamtBits, a, b)})
+	case ba < bb:
+		return op.newNode(ba|bb, a, b)
+	}
+	return op.newNode(ba|bb, b, a)
+}
+
+// assoc returns n with k set to v, and whether k was newly added.
+func (op hamtOp[K, V]) assoc(n *hamtNode[K, V], shift uint, h uint64, k K, v V) (*hamtNode[K, V], bool) {
+	leaf := hamtEntry[K, V]{hash: h, k: k, v: v}
+	if n == nil {
+		return op.newNode(hamtBit(h, shift), leaf), true
+	}
+	if n.bitmap == 0 {
+		ch := n.entries[0].hash
+		if ch != h {
+			// Push the collision node down a level so we can
+			// put the new key beside it.
+			wrap := op.newNode(hamtBit(ch, shift), hamtEntry[K, V]{hash: ch, child: n})
+			return op.assoc(wrap, shift, h, k, v)
+		}
+		for i := range n.entries {
+			if op.equal(n.entries[i].k, k) {
+				n = op.editable(n)
+				n.entries[i] = leaf
+				return n, false
+			}
+		}
+		n = op.editable(n)
+		n.entries = append(n.entries, leaf)
+		return n, true
+	}
+
+	bit := hamtBit(h, shift)
+	idx := n.index(bit)
+	if n.bitmap&bit == 0 {
+		n = op.editable(n)
+		n.bitmap |= bit
+		n.entries = slices.Insert(n.entries, idx, leaf)
+		return n, true
+	}
+	e := n.entries[idx]
+	if e.child != nil {
+		c, added := op.assoc(e.child, shift+hamtBits, h, k, v)
+		if c != e.child {
+			n = op.editable(n)
+			n.entries[idx].child = c
+		}
+		return n, added
+	}
+	if e.hash == h && op.equal(e.k, k) {
+		n = op.editable(n)
+		n.entries[idx] = leaf
+		return n, false
+	}
+	c := op.merge(shift+hamtBits, e, leaf)
+	n = op.editable(n)
+	n.entries[idx] = hamtEntry[K, V]{hash: e.hash, child: c}
+	return n, true
+}
+
+// dissoc returns n without k, and whether k was removed. It returns nil
+// if the resulting node would be empty. If the resulting node would
+// contain a single key/value pair, the caller should pull it up.
+func (op hamtOp[K, V]) dissoc(n *hamtNode[K, V], shift uint, h uint64, k K) (*hamtNode[K, V], bool) {
+	if n.bitmap == 0 {
+		for i := range n.entries {
+			if n.entries[i].hash != h || !op.equal(n.entries[i].k, k) {
+				continue
+			}
+			if len(n.entries) == 2 {
+				rest := n.entries[1-i]
+				return op.newNode(hamtBit(rest.hash, shift), rest), true
+			}
+			n = op.editable(n)
+			n.entries = slices.Delete(n.entries, i, i+1)
+			return n, true
+		}
+		return n, false
+	}
+
+	bit := hamtBit(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	idx := n.index(bit)
+	e := n.entries[idx]
+	if e.child != nil {
+		c, removed := op.dissoc(e.child, shift+hamtBits, h, k)
+		if !removed {
+			return n, false
+		}
+		if c == nil {
+			if len(n.entries) == 1 {
+				return nil, true
+			}
+			n = op.editable(n)
+			n.bitmap &^= bit
+			n.entries = slices.Delete(n.entries, idx, idx+1)
+			return n, true
+		}
+		n = op.editable(n)
+		if len(c.entries) == 1 && c.entries[0].child == nil {
+			// Pull the remaining pair up into this node.
+			n.entries[idx] = c.entries[0]
+		} else {
+			n.entries[idx].child = c
+		}
+		return n, true
+	}
+	if e.hash != h || !op.equal(e.k, k) {
+		return n, false
+	}
+	if len(n.entries) == 1 {
+		return nil, true
+	}
+	n = op.editable(n)
+	n.bitmap &^= bit
+	n.entries = slices.Delete(n.entries, idx, idx+1)
+	return n, true
+}
+
+func (op hamtOp[K, V]) get(n *hamtNode[K, V], h uint64, k K) (V, bool) {
+	for shift := uint(0); n != nil; shift += hamtBits {
+		if n.bitmap == 0 {
+			for _, e := range n.entries {
+				if e.hash == h && op.equal(e.k, k) {
+					return e.v, true
+				}
+			}
+			break
+		}
+		bit := hamtBit(h, shift)
+		if n.bitmap&bit == 0 {
+			break
+		}
+		e := &n.entries[n.index(bit)]
+		if e.child != nil {
+			n = e.child
+			continue
+		}
+		if e.hash == h && op.equal(e.k, k) {
+			return e.v, true
+		}
+		break
+	}
+	var v V
+	return v, false
+}
+
+func (n *hamtNode[K, V]) iterate(f func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	for i := range n.entries {
+		e := &n.entries[i]
+		if e.child != nil {
+			if !e.child.iterate(f) {
+				return false
+			}
+		} else if !f(e.k, e.v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *HashMap[K, V]) op() hamtOp[K, V] {
+	return hamtOp[K, V]{equal: m.equal}
+}
+
+// Assoc returns a new map, consisting of the original map with the
+// key/value pair `k`/`v` added to it.
+func (m *HashMap[K, V]) Assoc(k K, v V) *HashMap[K, V] {
+	root, added := m.op().assoc(m.root, 0, m.hash(k), k, v)
+	nm := &HashMap[K, V]{
+		hash:  m.hash,
+		equal: m.equal,
+		root:  root,
+		size:  m.size,
+	}
+	if added {
+		nm.size++
+	}
+	return nm
+}
+
+// Dissoc returns a new map that does not contain the key `k`. If `k`
+// is not present, Dissoc returns the original map.
+func (m *HashMap[K, V]) Dissoc(k K) *HashMap[K, V] {
+	if m.root == nil {
+		return m
+	}
+	root, removed := m.op().dissoc(m.root, 0, m.hash(k), k)
+	if !removed {
+		return m
+	}
+	return &HashMap[K, V]{
+		hash:  m.hash,
+		equal: m.equal,
+		root:  root,
+		size:  m.size - 1,
+	}
+}
+
+// Get looks up the element in the map associated with `k`.
+// It also returns a boolean indicating whether the value was found.
+func (m *HashMap[K, V]) Get(k K) (V, bool) {
+	return m.op().get(m.root, m.hash(k), k)
+}
+
+// Len returns the number of elements present in the map.
+func (m *HashMap[K, V]) Len() uint64 {
+	return m.size
+}
+
+// Iterate executes `f` over the key/value pairs in the map, until `f`
+// returns false. The order of iteration is unspecified, but is the same
+// every time a given map is iterated.
+func (m *HashMap[K, V]) Iterate(f func(K, V) bool) {
+	m.root.iterate(f)
+}
+
+// All returns an iterator over the key/value pairs in the map, in the
+// same order as Iterate.
+func (m *HashMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Iterate
+}
+
+// Keys returns a Seq containing the keys of the map, in the same order
+// as Iterate.
+func (m *HashMap[K, V]) Keys() Seq[K] {
+	return newHashSeq(m, keyOf[K, V])
+}
+
+// Values returns a Seq containing the values of the map, in the same
+// order as Iterate.
+func (m *HashMap[K, V]) Values() Seq[V] {
+	return newHashSeq(m, valueOf[K, V])
+}
+
+// Entries returns a Seq containing the key/value pairs of the map, in
+// the same order as Iterate.
+func (m *HashMap[K, V]) Entries() Seq[Pair[K, V]] {
+	return newHashSeq(m, MakePair[K, V])
+}
+
+// Transient returns a TransientHashMap containing the elements of `m`.
+// `m` is not affected by changes to the TransientHashMap.
+func (m *HashMap[K, V]) Transient() *TransientHashMap[K, V] {
+	return &TransientHashMap[K, V]{
+		m: HashMap[K, V]{
+			hash:  m.hash,
+			equal: m.equal,
+			root:  m.root,
+			size:  m.size,
+		},
+		edit: &hamtOwner{},
+	}
+}
+
+// TransientHashMap is a mutable version of HashMap, for building maps
+// efficiently. Its Assoc and Dissoc methods modify it in place, avoiding
+// the copying that HashMap's methods must do. Persistent turns it into a
+// HashMap once it is built.
+//
+// TransientHashMaps are not safe for concurrent use.
+type TransientHashMap[K, V any] struct {
+	m    HashMap[K, V]
+	edit *hamtOwner
+}
+
+func (t *TransientHashMap[K, V]) op() hamtOp[K, V] {
+	if t.edit == nil {
+		panic("TransientHashMap used after Persistent")
+	}
+	return hamtOp[K, V]{equal: t.m.equal, edit: t.edit}
+}
+
+// Assoc sets the value associated with `k` to `v`.
+func (t *TransientHashMap[K, V]) Assoc(k K, v V) {
+	root, added := t.op().assoc(t.m.root, 0, t.m.hash(k), k, v)
+	t.m.root = root
+	if added {
+		t.m.size++
+	}
+}
+
+// Dissoc removes `k` from the map, and returns a boolean indicating
+// whether or not it was present.
+func (t *TransientHashMap[K, V]) Dissoc(k K) bool {
+	op := t.op()
+	if t.m.root == nil {
+		return false
+	}
+	root, removed := op.dissoc(t.m.root, 0, t.m.hash(k), k)
+	if removed {
+		t.m.root = root
+		t.m.size--
+	}
+	return removed
+}
+
+// Get looks up the element in the map associated with `k`.
+// It also returns a boolean indicating whether the value was found.
+func (t *TransientHashMap[K, V]) Get(k K) (V, bool) {
+	return t.op().get(t.m.root, t.m.hash(k), k)
+}
+
+// Len returns the number of elements present in the map.
+func (t *TransientHashMap[K, V]) Len() uint64 {
+	return t.m.size
+}
+
+// Persistent returns a HashMap containing the elements of the
+// TransientHashMap. The TransientHashMap must not be used afterwards.
+func (t *TransientHashMap[K, V]) Persistent() *HashMap[K, V] {
+	t.op()
+	t.edit = nil
+	m := t.m
+	return &m
+}
+
+// hashSeq is a Seq over the entries of a HashMap, with indices in
+// [lo, hi). Each entry is converted to an element with proj.
+// HashMap nodes don't track their sizes, so Elem is O(n).
+type hashSeq[K, V, E any] struct {
+	root *hamtNode[K, V]
+	proj func(K, V) E
+	lo   uint64
+	hi   uint64
+}
+
+func newHashSeq[K, V, E any](m *HashMap[K, V], proj func(K, V) E) Seq[E] {
+	return &hashSeq[K, V, E]{
+		root: m.root,
+		proj: proj,
+		hi:   m.size,
+	}
+}
+
+func (s *hashSeq[K, V, E]) iterate(f func(K, V) bool) {
+	var i uint64
+	s.root.iterate(func(k K, v V) bool {
+		if i >= s.hi {
+			return false
+		}
+		i++
+		if i <= s.lo {
+			return true
+		}
+		return f(k, v)
+	})
+}
+
+func (s *hashSeq[K, V, E]) Elem(i uint64) (E, bool) {
+	var ret E
+	var found bool
+	if i < s.hi-s.lo {
+		(&hashSeq[K, V, E]{root: s.root, proj: s.proj, lo: s.lo + i, hi: s.hi}).iterate(func(k K, v V) bool {
+			ret, found = s.proj(k, v), true
+			return false
+		})
+	}
+	return ret, found
+}
+
+func (s *hashSeq[K, V, E]) Split(n uint64) (Seq[E], Seq[E]) {
+	if n >= s.hi-s.lo {
+		return s, (*Vec[E])(nil)
+	}
+	return s.Take(n), &hashSeq[K, V, E]{root: s.root, proj: s.proj, lo: s.lo + n, hi: s.hi}
+}
+
+func (s *hashSeq[K, V, E]) Take(n uint64) Seq[E] {
+	if n >= s.hi-s.lo {
+		return s
+	}
+	return &hashSeq[K, V, E]{root: s.root, proj: s.proj, lo: s.lo, hi: s.lo + n}
+}
+
+func (s *hashSeq[K, V, E]) Iterate(f func(E) bool) {
+	s.iterate(func(k K, v V) bool {
+		return f(s.proj(k, v))
+	})
+}
+
+func (s *hashSeq[K, V, E]) Lazy(f func(func() E) bool) {
+	s.iterate(func(k K, v V) bool {
+		return f(func() E { return s.proj(k, v) })
+	})
+}
+
+// comparableHash returns a function which hashes comparable keys of type K
+// with `seed`, such that keys which are == have the same hash.
+//
+// Keys whose memory representation determines their equality, such as
+// integers, pointers, and structs and arrays of them without padding, are
+// hashed as raw memory. Strings are hashed by their contents. Other keys,
+// such as floats and interfaces, are hashed by walking them with reflect.
+func comparableHash[K comparable](seed maphash.Seed) func(K) uint64 {
+	t := reflect.TypeFor[K]()
+	switch {
+	case t.Kind() == reflect.String:
+		return func(k K) uint64 {
+			return maphash.String(seed, *(*string)(unsafe.Pointer(&k)))
+		}
+	case memHashable(t):
+		return func(k K) uint64 {
+			return maphash.Bytes(seed, unsafe.Slice((*byte)(unsafe.Pointer(&k)), unsafe.Sizeof(k)))
+		}
+	}
+	return func(k K) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		hashValue(&h, reflect.ValueOf(&k).Elem())
+		return h.Sum64()
+	}
+}
+
+// memHashable returns true if values of type t are == exactly when their
+// memory is identical.
+func memHashable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+		return true
+	case reflect.Array:
+		return memHashable(t.Elem())
+	case reflect.Struct:
+		var size uintptr
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Name == "_" || !memHashable(f.Type) {
+				return false
+			}
+			size += f.Type.Size()
+		}
+		// Padding bytes are not compared, so they must not be hashed.
+		return size == t.Size()
+	}
+	return false
+}
+
+// hashValue writes v to h, such that values which are == write the same
+// bytes.
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	writeUint := func(u uint64) {
+		for i := range buf {
+			buf[i] = byte(u >> (8 * i))
+		}
+		h.Write(buf[:])
+	}
+	writeFloat := func(f float64) {
+		if f == 0 {
+			// +0 and -0 are ==.
+			f = 0
+		}
+		writeUint(math.Float64bits(f))
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(real(c))
+		writeFloat(imag(c))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+		writeUint(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != "_" {
+				hashValue(h, v.Field(i))
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		h.WriteByte(1)
+		hashValue(h, v.Elem())
+	default:
+		// The dynamic type of an interface key is not comparable.
+		// == would panic as well.
+		panic("ion: hash of unhashable type " + v.Type().String())
+	}
+}
@@ -0,0 +1,258 @@
+package ion
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func checkHashMap[K comparable, V comparable](t *testing.T, name string, m *HashMap[K, V], ref map[K]V) {
+	t.Helper()
+	if m.Len() != uint64(len(ref)) {
+		t.Fatalf("%s: Expected %d elements, but got %d", name, len(ref), m.Len())
+	}
+	for k, v := range ref {
+		if mv, ok := m.Get(k); !ok || mv != v {
+			t.Fatalf("%s: Expected %v => %v, but got %v (%t)", name, k, v, mv, ok)
+		}
+	}
+	var n int
+	for k, v := range m.All() {
+		if rv, ok := ref[k]; !ok || rv != v {
+			t.Fatalf("%s: Unexpected entry %v => %v", name, k, v)
+		}
+		n++
+	}
+	if n != len(ref) {
+		t.Fatalf("%s: Expected to iterate %d elements, but got %d", name, len(ref), n)
+	}
+}
+
+func testHashMap(t *testing.T, m *HashMap[int, int]) {
+	ref := make(map[int]int)
+	var versions []*HashMap[int, int]
+	var refs []map[int]int
+	for i := 0; i < 20000; i++ {
+		k := rand.Intn(5000)
+		if rand.Intn(3) == 0 {
+			m = m.Dissoc(k)
+			delete(ref, k)
+		} else {
+			m = m.Assoc(k, i)
+			ref[k] = i
+		}
+		if i%2000 == 0 {
+			versions = append(versions, m)
+			c := make(map[int]int)
+			for k, v := range ref {
+				c[k] = v
+			}
+			refs = append(refs, c)
+		}
+		if _, ok := m.Get(-1); ok {
+			t.Fatalf("Found a key that was never added")
+		}
+	}
+	checkHashMap(t, "final", m, ref)
+	// Earlier versions must not have been affected by later changes.
+	for i := range versions {
+		checkHashMap(t, "version "+strconv.Itoa(i), versions[i], refs[i])
+	}
+	for k := range ref {
+		m = m.Dissoc(k)
+	}
+	if m.Len() != 0 || m.root != nil {
+		t.Fatalf("Expected an empty map, but got %d elements", m.Len())
+	}
+	if m.Dissoc(1) != m {
+		t.Fatalf("Expected Dissoc of a missing key to return the original map")
+	}
+}
+
+func TestHashMap(t *testing.T) {
+	t.Run("comparable", func(t *testing.T) {
+		testHashMap(t, NewHashMap[int, int]())
+	})
+	t.Run("collisions", func(t *testing.T) {
+		// Only 16 distinct hashes, so most keys collide.
+		testHashMap(t, NewHashMapFunc[int, int](
+			func(k int) uint64 { return uint64(k%16) * 0x9E3779B97F4A7C15 },
+			func(a, b int) bool { return a == b },
+		))
+	})
+	t.Run("slice-keys", func(t *testing.T) {
+		// Slices are not comparable, so they need a hasher.
+		m := NewHashMapFunc[[]byte, int](
+			func(k []byte) uint64 {
+				var h uint64 = 14695981039346656037
+				for _, b := range k {
+					h = (h ^ uint64(b)) * 1099511628211
+				}
+				return h
+			},
+			func(a, b []byte) bool { return string(a) == string(b) },
+		)
+		for i := 0; i < 1000; i++ {
+			m = m.Assoc([]byte(strconv.Itoa(i)), i)
+		}
+		for i := 0; i < 1000; i++ {
+			if v, ok := m.Get([]byte(strconv.Itoa(i))); !ok || v != i {
+				t.Fatalf("Expected %d => %d, but got %d", i, i, v)
+			}
+		}
+	})
+}
+
+func TestTransientHashMap(t *testing.T) {
+	base := NewHashMap[int, int]()
+	for i := 0; i < 100; i++ {
+		base = base.Assoc(i, i)
+	}
+	tr := base.Transient()
+	for i := 0; i < 10000; i++ {
+		tr.Assoc(i, -i)
+	}
+	for i := 0; i < 10000; i += 2 {
+		if !tr.Dissoc(i) {
+			t.Fatalf("Expected to remove %d", i)
+		}
+	}
+	if tr.Dissoc(-5) {
+		t.Fatalf("Removed a key that was never added")
+	}
+	m := tr.Persistent()
+
+	ref := make(map[int]int)
+	for i := 1; i < 10000; i += 2 {
+		ref[i] = -i
+	}
+	checkHashMap(t, "transient", m, ref)
+
+	// The original map must not have been modified.
+	ref = make(map[int]int)
+	for i := 0; i < 100; i++ {
+		ref[i] = i
+	}
+	checkHashMap(t, "base", base, ref)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Expected use after Persistent to panic")
+			}
+		}()
+		tr.Assoc(1, 1)
+	}()
+
+	built := BuildHashMap(func(add func(string, int)) {
+		for i := 0; i < 1000; i++ {
+			add(strconv.Itoa(i), i)
+		}
+	})
+	if v, ok := built.Get("999"); built.Len() != 1000 || !ok || v != 999 {
+		t.Fatalf("Expected BuildHashMap to build 1000 elements")
+	}
+}
+
+func TestHashMapSeq(t *testing.T) {
+	m := BuildHashMap(func(add func(int, int)) {
+		for i := 0; i < 10000; i++ {
+			add(i, i*2)
+		}
+	})
+	keys := m.Keys()
+	l, r := keys.Split(5000)
+	var sum int
+	for _, s := range []Seq[int]{l, r} {
+		s.Iterate(func(k int) bool {
+			sum += k
+			return true
+		})
+	}
+	if sum != 49995000 {
+		t.Fatalf("Expected sum of 49995000, but got %d", sum)
+	}
+	if k, ok := r.Elem(0); !ok {
+		t.Fatalf("Expected an element")
+	} else if e, _ := keys.Elem(5000); e != k {
+		t.Fatalf("Expected Split to preserve order: %d != %d", e, k)
+	}
+	if _, ok := keys.Elem(10000); ok {
+		t.Fatalf("Expected no element at index 10000")
+	}
+	if n := len(ToSlice(m.Entries().Take(10))); n != 10 {
+		t.Fatalf("Expected 10 entries, but got %d", n)
+	}
+	m.Entries().Iterate(func(p Pair[int, int]) bool {
+		if p.Second != p.First*2 {
+			t.Fatalf("Expected %d => %d, but got %d", p.First, p.First*2, p.Second)
+		}
+		return true
+	})
+}
+
+func TestHashMapComparableKeys(t *testing.T) {
+	type padded struct {
+		b bool
+		i int64
+	}
+	type mixed struct {
+		s string
+		f float64
+		p *int
+	}
+	x, y := new(int), new(int)
+
+	t.Run("padded", func(t *testing.T) {
+		m := NewHashMap[padded, int]()
+		for i := 0; i < 1000; i++ {
+			m = m.Assoc(padded{i%2 == 0, int64(i)}, i)
+		}
+		for i := 0; i < 1000; i++ {
+			if v, ok := m.Get(padded{i%2 == 0, int64(i)}); !ok || v != i {
+				t.Fatalf("Expected %d, but got %d (%t)", i, v, ok)
+			}
+		}
+	})
+	t.Run("mixed", func(t *testing.T) {
+		m := NewHashMap[mixed, int]()
+		m = m.Assoc(mixed{"a", 0, x}, 1)
+		m = m.Assoc(mixed{"a", 1.5, y}, 2)
+		// -0 == +0, so this replaces the first key.
+		m = m.Assoc(mixed{"a", math.Copysign(0, -1), x}, 3)
+		if m.Len() != 2 {
+			t.Fatalf("Expected 2 keys, but got %d", m.Len())
+		}
+		if v, _ := m.Get(mixed{"a", 0, x}); v != 3 {
+			t.Fatalf("Expected 3, but got %d", v)
+		}
+		if _, ok := m.Get(mixed{"a", 0, y}); ok {
+			t.Fatalf("Found a key with a different pointer")
+		}
+	})
+	t.Run("interface", func(t *testing.T) {
+		m := NewHashMap[any, int]()
+		keys := []any{nil, 1, "1", int8(1), [2]int{1, 2}, padded{true, 1}, x}
+		for i, k := range keys {
+			m = m.Assoc(k, i)
+		}
+		for i, k := range keys {
+			if v, ok := m.Get(k); !ok || v != i {
+				t.Fatalf("Expected %v => %d, but got %d (%t)", k, i, v, ok)
+			}
+		}
+		if m.Len() != uint64(len(keys)) {
+			t.Fatalf("Expected %d keys, but got %d", len(keys), m.Len())
+		}
+	})
+	t.Run("array", func(t *testing.T) {
+		m := NewHashMap[[3]string, int]()
+		for i := 0; i < 500; i++ {
+			m = m.Assoc([3]string{strconv.Itoa(i), "x", strconv.Itoa(i * 2)}, i)
+		}
+		if v, ok := m.Get([3]string{"7", "x", "14"}); !ok || v != 7 {
+			t.Fatalf("Expected 7, but got %d (%t)", v, ok)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package ion
+
+import (
+	"iter"
+)
+
+// Values returns an iter.Seq[T] over the elements of `s`, so that it
+// can be used with range-over-func loops and the iterator functions
+// of the standard library, such as slices.Collect.
+//
+// For example:
+//
+//	for e := range Values(From[int](0, 1).Take(10)) {
+//		fmt.Printf("%d ", e)
+//	}
+//
+// Note: Ranging over an unbounded sequence will never terminate unless
+// the loop body breaks out of the loop.
+func Values[T any](s Seq[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.Iterate(yield)
+	}
+}
+
+// All returns an iter.Seq2[uint64, T] over the index/element pairs of `s`.
+//
+// Note: Ranging over an unbounded sequence will never terminate unless
+// the loop body breaks out of the loop.
+func All[T any](s Seq[T]) iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		var i uint64
+		s.Iterate(func(e T) bool {
+			if !yield(i, e) {
+				return false
+			}
+			i++
+			return true
+		})
+	}
+}
+
+// FromIter returns a Seq[T] containing the values produced by the
+// iterator `it`.
+//
+// The returned Seq is lazy, meaning `it` is only advanced as far as is
+// required to realize the elements requested from the Seq. Since iterators
+// may not be restartable, the values produced by `it` are memoized as with
+// StateGen, and `it` is only ever iterated once.
+//
+// `it` is driven with iter.Pull, which keeps resources associated with
+// the iteration until `it` is exhausted. If the returned Seq is not
+// iterated to the end, those resources are never released, so FromIter
+// is best used on bounded iterators or on Seqs which are fully consumed.
+func FromIter[T any](it iter.Seq[T]) Seq[T] {
+	next, stop := iter.Pull(it)
+	return StateGen(func() (T, bool) {
+		e, ok := next()
+		if !ok {
+			stop()
+		}
+		return e, ok
+	})
+}
+
+// Values returns an iter.Seq[T] over the elements of the Vec.
+func (s *Vec[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.iterate(yield)
+	}
+}
+
+// All returns an iter.Seq2[uint64, T] over the index/element pairs of the Vec.
+func (s *Vec[T]) All() iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		var i uint64
+		s.iterate(func(e T) bool {
+			if !yield(i, e) {
+				return false
+			}
+			i++
+			return true
+		})
+	}
+}
+
+// Backward returns an iter.Seq2[uint64, T] over the index/element pairs of
+// the Vec, traversing the Vec from the last element to the first.
+func (s *Vec[T]) Backward() iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		i := s.Len()
+		s.reverse(func(e T) bool {
+			i--
+			return yield(i, e)
+		})
+	}
+}
+
+func (s *Vec[T]) reverse(f func(T) bool) bool {
+	if s == nil {
+		return true
+	}
+	if s.r != nil {
+		switch o := s.r.(type) {
+		case *Vec[T]:
+			if !o.reverse(f) {
+				return false
+			}
+		case *seqLeaf[T]:
+			for i := len(o.seq) - 1; i >= 0; i-- {
+				if !f(o.seq[i]) {
+					return false
+				}
+			}
+		}
+	}
+	if s.l != nil {
+		switch o := s.l.(type) {
+		case *Vec[T]:
+			if !o.reverse(f) {
+				return false
+			}
+		case *seqLeaf[T]:
+			for i := len(o.seq) - 1; i >= 0; i-- {
+				if !f(o.seq[i]) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// All returns an iter.Seq2[T, U] over the key/value pairs of the tree,
+// in ascending key order.
+func (t *AVLTree[T, U]) All() iter.Seq2[T, U] {
+	return func(yield func(T, U) bool) {
+		t.iterate(yield)
+	}
+}
+
+// Backward returns an iter.Seq2[T, U] over the key/value pairs of the tree,
+// in descending key order.
+func (t *AVLTree[T, U]) Backward() iter.Seq2[T, U] {
+	return func(yield func(T, U) bool) {
+		t.reverse(yield)
+	}
+}
+
+func (t *AVLTree[T, U]) iterate(f func(T, U) bool) bool {
+	if t == nil {
+		return true
+	}
+	return t.l.iterate(f) && f(t.k, t.v) && t.r.iterate(f)
+}
+
+func (t *AVLTree[T, U]) reverse(f func(T, U) bool) bool {
+	if t == nil {
+		return true
+	}
+	return t.r.reverse(f) && f(t.k, t.v) && t.l.reverse(f)
+}
+
+// All returns an iter.Seq2[T, U] over the key/value pairs of the tree,
+// in ascending key order.
+func (r *RBTree[T, U]) All() iter.Seq2[T, U] {
+	return func(yield func(T, U) bool) {
+		r.iterate(yield)
+	}
+}
+
+// Backward returns an iter.Seq2[T, U] over the key/value pairs of the tree,
+// in descending key order.
+func (r *RBTree[T, U]) Backward() iter.Seq2[T, U] {
+	return func(yield func(T, U) bool) {
+		r.reverse(yield)
+	}
+}
+
+func (r *RBTree[T, U]) iterate(f func(T, U) bool) bool {
+	if r == nil {
+		return true
+	}
+	return r.l.iterate(f) && f(r.k, r.v) && r.r.iterate(f)
+}
+
+func (r *RBTree[T, U]) reverse(f func(T, U) bool) bool {
+	if r == nil {
+		return true
+	}
+	return r.r.reverse(f) && f(r.k, r.v) && r.l.reverse(f)
+}
@@ -0,0 +1,131 @@
+package ion
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	var res []int
+	for e := range Values(From[int](0, 1)) {
+		if e == 10 {
+			break
+		}
+		res = append(res, e)
+	}
+	if !slices.Equal(res, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatalf("Expected 0..9, but got %v", res)
+	}
+
+	if s := slices.Collect(Values(From[int](0, 2).Take(5))); !slices.Equal(s, []int{0, 2, 4, 6, 8}) {
+		t.Fatalf("Expected [0 2 4 6 8], but got %v", s)
+	}
+}
+
+func TestAll(t *testing.T) {
+	for i, e := range All(From[int](100, 1).Take(1000)) {
+		if e != int(i)+100 {
+			t.Fatalf("Expected s[%d] == %d, but was %d", i, i+100, e)
+		}
+	}
+}
+
+func TestFromIter(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, FromIter(func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, FromIter(slices.Values(ToSlice(From[int](0, 1).Take(10000)))))
+	})
+	t.Run("once", func(t *testing.T) {
+		var calls int
+		s := FromIter(func(yield func(int) bool) {
+			calls++
+			for i := 0; i < 100; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		})
+		if l := len(ToSlice(s)); l != 100 {
+			t.Fatalf("Expected 100 elements, but got %d", l)
+		}
+		if l := len(ToSlice(s)); l != 100 {
+			t.Fatalf("Expected 100 elements, but got %d", l)
+		}
+		if calls != 1 {
+			t.Fatalf("Expected the iterator to be run once, but was run %d times", calls)
+		}
+	})
+}
+
+func TestVecIter(t *testing.T) {
+	v := BuildVec(func(add func(uint64)) {
+		for i := uint64(0); i < 1000; i++ {
+			add(i)
+		}
+	})
+
+	if s := slices.Collect(v.Values()); !slices.Equal(s, asSlice(v)) {
+		t.Fatalf("Values did not match the contents of the Vec")
+	}
+	for i, e := range v.All() {
+		if e != i {
+			t.Fatalf("Expected v[%d] == %d, but was %d", i, i, e)
+		}
+	}
+
+	next := uint64(999)
+	for i, e := range v.Backward() {
+		if i != next || e != next {
+			t.Fatalf("Expected (%d, %d), but got (%d, %d)", next, next, i, e)
+		}
+		next--
+	}
+	if next != ^uint64(0) {
+		t.Fatalf("Backward stopped early, at %d", next)
+	}
+}
+
+func TestTreeIter(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range []int{5, 3, 9, 1, 4, 7, 8, 2, 6, 0} {
+		avl = avl.Insert(i, i*10)
+		rb = rb.Insert(i, i*10)
+	}
+
+	check := func(t *testing.T, forward, backward func(func(int, int) bool)) {
+		next := 0
+		for k, v := range forward {
+			if k != next || v != next*10 {
+				t.Fatalf("Expected (%d, %d), but got (%d, %d)", next, next*10, k, v)
+			}
+			next++
+		}
+		if next != 10 {
+			t.Fatalf("Expected 10 entries, but got %d", next)
+		}
+		for k, v := range backward {
+			next--
+			if k != next || v != next*10 {
+				t.Fatalf("Expected (%d, %d), but got (%d, %d)", next, next*10, k, v)
+			}
+		}
+		if next != 0 {
+			t.Fatalf("Backward stopped early, at %d", next)
+		}
+	}
+	t.Run("avl", func(t *testing.T) {
+		check(t, avl.All(), avl.Backward())
+	})
+	t.Run("rb", func(t *testing.T) {
+		check(t, rb.All(), rb.Backward())
+	})
+}
@@ -2,6 +2,7 @@ package ion
 
 import (
 	"math"
+	"sync"
 
 	"golang.org/x/exp/constraints"
 )
@@ -163,6 +164,10 @@ type repseq[T any] struct {
 }
 
 func (r *repseq[T]) Elem(i uint64) (T, bool) {
+	if r.limit > 0 && i >= r.limit {
+		var ret T
+		return ret, false
+	}
 	return r.e, true
 }
 
@@ -173,8 +178,8 @@ func (r *repseq[T]) Split(n uint64) (Seq[T], Seq[T]) {
 	}
 
 	if r.limit > 0 {
-		if n == r.limit {
-			return s, (*Vec[T])(nil)
+		if n >= r.limit {
+			return r, (*Vec[T])(nil)
 		}
 		return s, &repseq[T]{
 			e:     r.e,
@@ -186,9 +191,13 @@ func (r *repseq[T]) Split(n uint64) (Seq[T], Seq[T]) {
 }
 
 func (r *repseq[T]) Take(n uint64) Seq[T] {
+	lim := n
+	if r.limit > 0 && r.limit < n {
+		lim = r.limit
+	}
 	return &repseq[T]{
 		e:     r.e,
-		limit: n,
+		limit: lim,
 	}
 }
 
@@ -261,7 +270,7 @@ func (r *genseq[T]) Elem(i uint64) (T, bool) {
 }
 
 func (r *genseq[T]) Split(n uint64) (Seq[T], Seq[T]) {
-	if r.limit > 0 && n > r.limit {
+	if r.limit > 0 && n >= r.limit {
 		return r, (*Vec[T])(nil)
 	}
 	s := &genseq[T]{
@@ -274,10 +283,14 @@ func (r *genseq[T]) Split(n uint64) (Seq[T], Seq[T]) {
 	if !ok {
 		return r, (*Vec[T])(nil)
 	}
+	var lim uint64
+	if r.limit > 0 {
+		lim = r.limit - n
+	}
 	nr := &genseq[T]{
 		start: e,
 		by:    r.by,
-		limit: r.limit - n,
+		limit: lim,
 	}
 	return s, nr
 }
@@ -337,6 +350,11 @@ type generateSeq[T, U any] struct {
 	f     func(state U) (T, U, bool)
 	state U
 	limit uint64
+	// cp holds the checkpoints shared by every Seq split or taken from
+	// the same GenerateWith call, and off is the index of state
+	// relative to the checkpoints.
+	cp  *genCheckpoints[U]
+	off uint64
 }
 
 // Generate takes a func `f` and executes it in order to generate
@@ -359,25 +377,137 @@ func GenerateInit[T, U any](state U, f func(state U) (T, U, bool)) Seq[T] {
 	}
 }
 
-func (g *generateSeq[T, U]) Elem(i uint64) (T, bool) {
-	if g.limit > 0 && i >= g.limit {
-		var ret T
-		return ret, false
+// GenerateOpts configures the state checkpoints kept by GenerateWith.
+type GenerateOpts struct {
+	// Every is the number of elements between checkpoints. If Every is
+	// 0, no checkpoints are kept, and GenerateWith behaves like
+	// GenerateInit.
+	Every uint64
+	// Max is the maximum number of checkpoints kept. When there are
+	// more than Max checkpoints, every other one is dropped and the
+	// spacing between them is doubled. If Max is 0, the number of
+	// checkpoints is not limited.
+	Max int
+}
+
+// GenerateWith is like GenerateInit, but keeps checkpoints of the
+// generator's state as elements are generated, as configured by `opts`.
+//
+// Without checkpoints, Elem(i) and Split(i) must run the generator i
+// times from the initial state, so random access on the Seq is
+// quadratic. With checkpoints, they resume from the nearest checkpoint
+// at or before i. The checkpoints are shared between the resulting Seq
+// and any Seqs split or taken from it, and are also recorded by Iterate.
+func GenerateWith[T, U any](state U, opts GenerateOpts, f func(state U) (T, U, bool)) Seq[T] {
+	g := &generateSeq[T, U]{
+		f:     f,
+		state: state,
 	}
-	var res T
+	if opts.Every > 0 {
+		g.cp = &genCheckpoints[U]{
+			every:  opts.Every,
+			max:    opts.Max,
+			states: []U{state},
+		}
+	}
+	return g
+}
+
+// genCheckpoints holds the state of a generator at evenly spaced indexes.
+// states[j] is the state from which the element at index j*every is
+// generated.
+type genCheckpoints[U any] struct {
+	sync.Mutex
+	every  uint64
+	max    int
+	states []U
+}
+
+// seek returns the latest checkpoint at or before index i, and the index
+// at which the next checkpoint should be recorded.
+func (c *genCheckpoints[U]) seek(i uint64) (uint64, U, uint64) {
+	c.Lock()
+	defer c.Unlock()
+	j := min(i/c.every, uint64(len(c.states)-1))
+	return j * c.every, c.states[j], uint64(len(c.states)) * c.every
+}
+
+// record records `state` as the checkpoint for index i, if i is the index
+// of the next checkpoint. It returns the index at which the next
+// checkpoint should be recorded.
+func (c *genCheckpoints[U]) record(i uint64, state U) uint64 {
+	c.Lock()
+	defer c.Unlock()
+	if i == uint64(len(c.states))*c.every {
+		c.states = append(c.states, state)
+		if c.max > 0 && len(c.states) > c.max {
+			n := 0
+			for j := 0; j < len(c.states); j += 2 {
+				c.states[n] = c.states[j]
+				n++
+			}
+			clear(c.states[n:])
+			c.states = c.states[:n]
+			c.every *= 2
+		}
+	}
+	return uint64(len(c.states)) * c.every
+}
+
+// stateAt returns the state from which the element at index i of g is
+// generated. If g ends before i, it returns false.
+func (g *generateSeq[T, U]) stateAt(i uint64) (U, bool) {
 	state := g.state
-	for j := uint64(0); j <= i; j++ {
+	if g.cp == nil {
+		for j := uint64(0); j < i; j++ {
+			var cont bool
+			_, state, cont = g.f(state)
+			if !cont {
+				return state, false
+			}
+		}
+		return state, true
+	}
+
+	target := g.off + i
+	pos, cpstate, next := g.cp.seek(target)
+	if pos > g.off {
+		state = cpstate
+	} else {
+		pos = g.off
+	}
+	for ; pos < target; pos++ {
+		if pos == next {
+			next = g.cp.record(pos, state)
+		}
 		var cont bool
-		res, state, cont = g.f(state)
+		_, state, cont = g.f(state)
 		if !cont {
-			return res, false
+			return state, false
 		}
 	}
-	return res, true
+	if pos == next {
+		g.cp.record(pos, state)
+	}
+	return state, true
+}
+
+func (g *generateSeq[T, U]) Elem(i uint64) (T, bool) {
+	if g.limit > 0 && i >= g.limit {
+		var ret T
+		return ret, false
+	}
+	state, ok := g.stateAt(i)
+	if !ok {
+		var ret T
+		return ret, false
+	}
+	res, _, cont := g.f(state)
+	return res, cont
 }
 
 func (g *generateSeq[T, U]) Split(n uint64) (Seq[T], Seq[T]) {
-	if g.limit > 0 && n > g.limit {
+	if g.limit > 0 && n >= g.limit {
 		return g, (*Vec[T])(nil)
 	}
 
@@ -385,28 +515,32 @@ func (g *generateSeq[T, U]) Split(n uint64) (Seq[T], Seq[T]) {
 		f:     g.f,
 		state: g.state,
 		limit: n,
+		cp:    g.cp,
+		off:   g.off,
 	}
 
 	// We need to generate the state for the remaining seq
-	state := g.state
-	for i := uint64(0); i < n; i++ {
-		var cont bool
-		_, state, cont = g.f(state)
-		if !cont {
-			// We didn't reach n
-			r := (*Vec[T])(nil)
-			return l, r
-		}
+	state, ok := g.stateAt(n)
+	if !ok {
+		// We didn't reach n
+		r := (*Vec[T])(nil)
+		return l, r
 	}
 
 	var right Seq[T]
 	if g.limit > 0 && n >= g.limit {
 		right = (*Vec[T])(nil)
 	} else {
+		var lim uint64
+		if g.limit > 0 {
+			lim = g.limit - n
+		}
 		right = &generateSeq[T, U]{
 			f:     g.f,
 			state: state,
-			limit: g.limit - n,
+			limit: lim,
+			cp:    g.cp,
+			off:   g.off + n,
 		}
 	}
 	return l, right
@@ -422,39 +556,29 @@ func (g *generateSeq[T, U]) Take(n uint64) Seq[T] {
 		f:     g.f,
 		state: g.state,
 		limit: lim,
+		cp:    g.cp,
+		off:   g.off,
 	}
 }
 
 func (g *generateSeq[T, U]) Iterate(f func(T) bool) {
 	state := g.state
-	if g.limit > 0 {
-		var i uint64
-		for {
-			var e T
-			var cont bool
-			if i == g.limit {
-				return
-			}
-			i++
-			e, state, cont = g.f(state)
-			if !cont {
-				return
-			}
-			if !f(e) {
-				return
-			}
+	next := uint64(math.MaxUint64)
+	if g.cp != nil {
+		_, _, next = g.cp.seek(g.off)
+	}
+	for i := uint64(0); g.limit == 0 || i < g.limit; i++ {
+		if g.off+i == next {
+			next = g.cp.record(g.off+i, state)
 		}
-	} else {
-		for {
-			var e T
-			var cont bool
-			e, state, cont = g.f(state)
-			if !cont {
-				return
-			}
-			if !f(e) {
-				return
-			}
+		var e T
+		var cont bool
+		e, state, cont = g.f(state)
+		if !cont {
+			return
+		}
+		if !f(e) {
+			return
 		}
 	}
 }
@@ -464,37 +588,9 @@ func (g *generateSeq[T, U]) Lazy(f func(func() T) bool) {
 	// elem i-1, and we cannot guarantee the execution order of the
 	// thunks we return. Instead, we evaluate the current element and
 	// return a closure that returns it.
-	state := g.state
-	if g.limit > 0 {
-		var i uint64
-		for {
-			var e T
-			var cont bool
-			if i == g.limit {
-				return
-			}
-			i++
-			e, state, cont = g.f(state)
-			if !cont {
-				return
-			}
-			if !f(func() T { return e }) {
-				return
-			}
-		}
-	} else {
-		for {
-			var e T
-			var cont bool
-			e, state, cont = g.f(state)
-			if !cont {
-				return
-			}
-			if !f(func() T { return e }) {
-				return
-			}
-		}
-	}
+	g.Iterate(func(e T) bool {
+		return f(func() T { return e })
+	})
 }
 
 // Fold folds a Seq[T] `s` into a value of type U, based on  the function `f`.
@@ -559,7 +655,7 @@ func (f *filterSeq[T]) Elem(i uint64) (T, bool) {
 }
 
 func (f *filterSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
-	if f.limit > 0 && n > f.limit {
+	if f.limit > 0 && n >= f.limit {
 		return f, (*Vec[T])(nil)
 	}
 
@@ -579,10 +675,14 @@ func (f *filterSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
 		})
 	})
 	_, rr := f.s.Split(split)
+	var lim uint64
+	if f.limit > 0 {
+		lim = f.limit - n
+	}
 	r := &filterSeq[T]{
 		s:     rr,
 		f:     f.f,
-		limit: f.limit - n,
+		limit: lim,
 	}
 	return l, r
 }
@@ -657,6 +757,225 @@ func Filter[T any](s Seq[T], f func(T) bool) Seq[T] {
 	}
 }
 
+// FilterOpts configures the index kept by FilterWith.
+type FilterOpts struct {
+	// Every is the spacing of the index. The source position of every
+	// Every'th match is recorded, and other matches are found by scanning
+	// forward from the nearest recorded one. If Every is 0 or 1, the
+	// position of every match is recorded.
+	Every uint64
+}
+
+// FilterWith is like Filter, but records the positions in `s` of the
+// elements that match `f`, as configured by `opts`.
+//
+// Without an index, Elem(i) and Split(i) on a filtered Seq must apply `f`
+// to the elements of `s` from the beginning each time. With an index,
+// Elem jumps to the recorded position in `s` and Split and Take run in
+// constant time. `s` is only scanned beyond the last recorded match. The
+// index is shared between the resulting Seq and any Seqs split or taken
+// from it. Because Elem and Split reach into `s` by position, `s` should
+// support efficient Elem and Split, as a Vec does.
+func FilterWith[T any](s Seq[T], opts FilterOpts, f func(T) bool) Seq[T] {
+	return &indexedFilterSeq[T]{
+		x: &filterIndex[T]{
+			s:     s,
+			f:     f,
+			every: max(opts.Every, 1),
+		},
+	}
+}
+
+// filterIndex records the positions in s of the elements matching f.
+//
+// f runs without the lock held, so it may use the filtered Seq itself, and
+// a slow f does not hold up other readers of the index.
+type filterIndex[T any] struct {
+	sync.Mutex
+	s     Seq[T]
+	f     func(T) bool
+	every uint64
+	// pos[j] is the position in s of match j*every. pos is only ever
+	// appended to, so a copy of it taken with the lock held may be read
+	// without it.
+	pos []uint64
+	// n is the number of matches among the first src elements of s,
+	// which have all been scanned.
+	src  uint64
+	n    uint64
+	done bool
+}
+
+// from returns s starting at position p.
+func (x *filterIndex[T]) from(p uint64) Seq[T] {
+	if p == 0 {
+		return x.s
+	}
+	_, r := x.s.Split(p)
+	return r
+}
+
+// matches returns true if the element e at position p is match c, where
+// pos and src were read from x together. Where the index already knows the
+// answer, f is not called.
+func (x *filterIndex[T]) matches(pos []uint64, src, p, c uint64, e T) bool {
+	if p < src && c%x.every == 0 {
+		j := c / x.every
+		return j < uint64(len(pos)) && pos[j] == p
+	}
+	return x.f(e)
+}
+
+// find returns match m and its position in s. If there are not more than
+// m matches, it returns false.
+func (x *filterIndex[T]) find(m uint64) (T, uint64, bool) {
+	x.Lock()
+	pos, src, n, done := x.pos, x.src, x.n, x.done
+	x.Unlock()
+	if m >= n {
+		return x.extend(m, src, n, done)
+	}
+	j := m / x.every
+	p, c := pos[j], j*x.every
+	if c == m {
+		e, ok := x.s.Elem(p)
+		return e, p, ok
+	}
+	var res T
+	var found bool
+	x.from(p).Iterate(func(e T) bool {
+		if x.matches(pos, src, p, c, e) {
+			if c == m {
+				res = e
+				found = true
+				return false
+			}
+			c++
+		}
+		p++
+		return true
+	})
+	return res, p, found
+}
+
+// extend scans s from position src, where n matches have been found, until
+// it finds match m, and then records the positions it found. Concurrent
+// scans over the same elements find the same matches, so the positions are
+// only recorded by whichever gets furthest.
+func (x *filterIndex[T]) extend(m, src, n uint64, done bool) (T, uint64, bool) {
+	var res T
+	var found bool
+	if done {
+		return res, 0, false
+	}
+	// pos[0] is the position of match first*every.
+	first := (n + x.every - 1) / x.every
+	var pos []uint64
+	x.from(src).Iterate(func(e T) bool {
+		if x.f(e) {
+			if n%x.every == 0 {
+				pos = append(pos, src)
+			}
+			if n == m {
+				res = e
+				found = true
+			}
+			n++
+		}
+		src++
+		return !found
+	})
+
+	x.Lock()
+	if src >= x.src {
+		x.pos = append(x.pos, pos[uint64(len(x.pos))-first:]...)
+		x.src, x.n = src, n
+		if !found {
+			x.done = true
+		}
+	}
+	x.Unlock()
+	if !found {
+		return res, 0, false
+	}
+	return res, src - 1, true
+}
+
+type indexedFilterSeq[T any] struct {
+	x *filterIndex[T]
+	// off is the number of matches before the first element of this
+	// Seq.
+	off   uint64
+	limit uint64
+}
+
+func (f *indexedFilterSeq[T]) Elem(i uint64) (T, bool) {
+	if f.limit > 0 && i >= f.limit {
+		var ret T
+		return ret, false
+	}
+	e, _, ok := f.x.find(f.off + i)
+	return e, ok
+}
+
+func (f *indexedFilterSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	if f.limit > 0 && n >= f.limit {
+		return f, (*Vec[T])(nil)
+	}
+	if n == 0 {
+		return (*Vec[T])(nil), f
+	}
+	var lim uint64
+	if f.limit > 0 {
+		lim = f.limit - n
+	}
+	l := &indexedFilterSeq[T]{x: f.x, off: f.off, limit: n}
+	r := &indexedFilterSeq[T]{x: f.x, off: f.off + n, limit: lim}
+	return l, r
+}
+
+func (f *indexedFilterSeq[T]) Take(n uint64) Seq[T] {
+	if n == 0 {
+		return (*Vec[T])(nil)
+	}
+	lim := n
+	if f.limit > 0 && f.limit < n {
+		lim = f.limit
+	}
+	return &indexedFilterSeq[T]{x: f.x, off: f.off, limit: lim}
+}
+
+func (f *indexedFilterSeq[T]) Iterate(fn func(T) bool) {
+	_, p, ok := f.x.find(f.off)
+	if !ok {
+		return
+	}
+	f.x.Lock()
+	pos, src := f.x.pos, f.x.src
+	f.x.Unlock()
+	var i uint64
+	f.x.from(p).Iterate(func(e T) bool {
+		if !f.x.matches(pos, src, p, f.off+i, e) {
+			p++
+			return true
+		}
+		if f.limit > 0 && i == f.limit {
+			return false
+		}
+		p++
+		i++
+		return fn(e)
+	})
+}
+
+func (f *indexedFilterSeq[T]) Lazy(fn func(func() T) bool) {
+	// The filter must evaluate each element anyway, so there is
+	// nothing to gain from deferring the work.
+	f.Iterate(func(e T) bool {
+		return fn(func() T { return e })
+	})
+}
+
 // ToSlice converts a Seq[T] into a []T.
 //
 // Note: Running ToSlice on an unbounded sequence will never terminate.
@@ -3,6 +3,8 @@ package ion
 import (
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -414,3 +416,294 @@ func BenchmarkPrimes(b *testing.B) {
 		}
 	})
 }
+
+func TestGenerateWith(t *testing.T) {
+	var calls atomic.Int64
+	fib := func(state [2]int) (int, [2]int, bool) {
+		calls.Add(1)
+		return state[0], [2]int{state[1], state[0] + state[1]}, true
+	}
+	plain := GenerateInit([2]int{0, 1}, fib)
+	seq := GenerateWith([2]int{0, 1}, GenerateOpts{Every: 16}, fib)
+	for i := uint64(0); i < 80; i++ {
+		e, _ := plain.Elem(i)
+		if g, ok := seq.Elem(i); !ok || g != e {
+			t.Fatalf("Expected seq[%d] == %d, but got %d", i, e, g)
+		}
+	}
+
+	// Random access should resume from a checkpoint rather than the start.
+	calls.Store(0)
+	for i := uint64(0); i < 80; i++ {
+		seq.Elem(79 - i)
+	}
+	if n := calls.Load(); n > 80*17 {
+		t.Fatalf("Expected at most %d calls to the generator, but got %d", 80*17, n)
+	}
+
+	_, right := seq.Split(50)
+	left, _ := right.Split(20)
+	for i := uint64(0); i < 20; i++ {
+		e, _ := plain.Elem(50 + i)
+		if g, ok := left.Elem(i); !ok || g != e {
+			t.Fatalf("Expected left[%d] == %d, but got %d", i, e, g)
+		}
+	}
+	if _, ok := left.Elem(20); ok {
+		t.Fatalf("Expected no element at index 20")
+	}
+	testFinSeq(t, GenerateWith(0, GenerateOpts{Every: 100}, func(i int) (int, int, bool) {
+		return i, i + 1, true
+	}).Take(10000))
+}
+
+func TestGenerateWithMax(t *testing.T) {
+	seq := GenerateWith(0, GenerateOpts{Every: 1, Max: 8}, func(i int) (int, int, bool) {
+		return i, i + 1, i < 100000
+	})
+	ToSlice(seq)
+	cp := seq.(*generateSeq[int, int]).cp
+	if len(cp.states) > 8 {
+		t.Fatalf("Expected at most 8 checkpoints, but got %d", len(cp.states))
+	}
+	for j, s := range cp.states {
+		if uint64(s) != uint64(j)*cp.every {
+			t.Fatalf("Expected checkpoint %d to hold state %d, but got %d", j, uint64(j)*cp.every, s)
+		}
+	}
+	if e, ok := seq.Elem(99999); !ok || e != 99999 {
+		t.Fatalf("Expected seq[99999] == 99999, but got %d", e)
+	}
+	if _, ok := seq.Elem(100000); ok {
+		t.Fatalf("Expected no element at index 100000")
+	}
+}
+
+func TestGenerateWithConcurrent(t *testing.T) {
+	seq := GenerateWith(0, GenerateOpts{Every: 10, Max: 64}, func(i int) (int, int, bool) {
+		return i * 2, i + 1, true
+	})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := uint64(g); i < 5000; i += 37 {
+				if e, ok := seq.Elem(i); !ok || e != int(i*2) {
+					t.Errorf("Expected seq[%d] == %d, but got %d", i, i*2, e)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestFilterWith(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+	src := BuildVec(func(add func(int)) {
+		for i := 0; i < 10000; i++ {
+			add(i * 3)
+		}
+	})
+	for _, every := range []uint64{0, 1, 7, 64} {
+		plain := Filter[int](src, even)
+		seq := FilterWith[int](src, FilterOpts{Every: every}, even)
+		for _, i := range []uint64{4000, 0, 17, 4999, 5000, 2500, 1} {
+			e, eok := plain.Elem(i)
+			if g, ok := seq.Elem(i); ok != eok || g != e {
+				t.Fatalf("every=%d: Expected seq[%d] == %d (%t), but got %d (%t)", every, i, e, eok, g, ok)
+			}
+		}
+		l, r := seq.Split(1000)
+		rl, rr := r.Split(1000)
+		for i, s := range []Seq[int]{l, rl, rr} {
+			e, _ := plain.Elem(uint64(i * 1000))
+			if g, ok := s.Elem(0); !ok || g != e {
+				t.Fatalf("every=%d: Expected split %d to start with %d, but got %d", every, i, e, g)
+			}
+		}
+		if n := len(ToSlice(rl)); n != 1000 {
+			t.Fatalf("every=%d: Expected 1000 elements, but got %d", every, n)
+		}
+		if n := len(ToSlice(rr)); n != 3000 {
+			t.Fatalf("every=%d: Expected 3000 elements, but got %d", every, n)
+		}
+		if n := len(ToSlice(rr.Take(10))); n != 10 {
+			t.Fatalf("every=%d: Expected 10 elements, but got %d", every, n)
+		}
+	}
+	testFinSeq(t, FilterWith(From[int](-100, 1), FilterOpts{Every: 10}, func(i int) bool {
+		return i >= 0
+	}).Take(10000))
+}
+
+func TestFilterWithCalls(t *testing.T) {
+	var calls atomic.Int64
+	seq := FilterWith(From[int](0, 1), FilterOpts{Every: 8}, func(i int) bool {
+		calls.Add(1)
+		return i%3 == 0
+	})
+	if e, ok := seq.Elem(1000); !ok || e != 3000 {
+		t.Fatalf("Expected seq[1000] == 3000, but got %d", e)
+	}
+	// Looking up earlier elements should only scan from the nearest
+	// recorded match.
+	calls.Store(0)
+	for i := uint64(0); i < 1000; i++ {
+		if e, ok := seq.Elem(i); !ok || e != int(i*3) {
+			t.Fatalf("Expected seq[%d] == %d, but got %d", i, i*3, e)
+		}
+	}
+	if n := calls.Load(); n > 1000*3*8 {
+		t.Fatalf("Expected at most %d calls to the predicate, but got %d", 1000*3*8, n)
+	}
+}
+
+func TestFilterWithIterateCalls(t *testing.T) {
+	var calls atomic.Int64
+	seq := FilterWith(From[int](0, 1), FilterOpts{}, func(i int) bool {
+		calls.Add(1)
+		return i%3 == 0
+	})
+	if e, ok := seq.Elem(1000); !ok || e != 3000 {
+		t.Fatalf("Expected seq[1000] == 3000, but got %d", e)
+	}
+	// Every position up to match 1000 is indexed, so iterating them
+	// shouldn't call the predicate again.
+	calls.Store(0)
+	_, r := seq.Split(10)
+	var i int
+	r.Take(990).Iterate(func(e int) bool {
+		if e != (i+10)*3 {
+			t.Fatalf("Expected %d, but got %d", (i+10)*3, e)
+		}
+		i++
+		return true
+	})
+	if i != 990 {
+		t.Fatalf("Expected 990 elements, but got %d", i)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("Expected no calls to the predicate, but got %d", n)
+	}
+}
+
+func TestFilterWithReentrant(t *testing.T) {
+	// The predicate looks up an earlier match of the Seq it filters.
+	var seq Seq[int]
+	seq = FilterWith(From[int](0, 1), FilterOpts{Every: 4}, func(i int) bool {
+		if i > 100 {
+			if e, ok := seq.Elem(3); !ok || e != 6 {
+				t.Errorf("Expected seq[3] == 6, but got %d", e)
+			}
+		}
+		return i%2 == 0
+	})
+	done := make(chan int)
+	go func() {
+		e, _ := seq.Elem(500)
+		done <- e
+	}()
+	select {
+	case e := <-done:
+		if e != 1000 {
+			t.Fatalf("Expected seq[500] == 1000, but got %d", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out. The predicate deadlocked on the index.")
+	}
+}
+
+func TestFilterWithConcurrent(t *testing.T) {
+	seq := FilterWith(From[int](0, 1), FilterOpts{Every: 4}, func(i int) bool {
+		return i%5 == 0
+	})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := uint64(5000 - g); i > 0; i -= min(i, 97) {
+				if e, ok := seq.Elem(i); !ok || e != int(i*5) {
+					t.Errorf("Expected seq[%d] == %d, but got %d", i, i*5, e)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// Regression test. Splitting a limited Seq exactly at its limit used to
+// leave the right half unbounded, and splitting an unbounded Seq
+// computed the limit of the right half as 0 - n, which wrapped around
+// to nearly math.MaxUint64.
+func TestSplitLimit(t *testing.T) {
+	gen := func() Seq[int] {
+		return Generate(func(state int) (int, int, bool) {
+			return state, state + 1, true
+		})
+	}
+	even := func(i int) bool { return i%2 == 0 }
+	t.Run("at-limit", func(t *testing.T) {
+		for name, s := range map[string]Seq[int]{
+			"from":     From[int](0, 1).Take(10),
+			"generate": gen().Take(10),
+			"filter":   Filter(From[int](0, 1), even).Take(10),
+		} {
+			l, r := s.Split(10)
+			if n := len(ToSlice(l)); n != 10 {
+				t.Fatalf("%s: Expected 10 elements on the left, but got %d", name, n)
+			}
+			if e, ok := r.Elem(0); ok {
+				t.Fatalf("%s: Expected the right half to be empty, but it starts with %d", name, e)
+			}
+		}
+	})
+	t.Run("unbounded", func(t *testing.T) {
+		_, r := From[int](0, 1).Split(5)
+		if l := r.(*genseq[int]).limit; l != 0 {
+			t.Fatalf("from: Expected an unbounded right half, but its limit is %d", l)
+		}
+		_, r = gen().Split(5)
+		if l := r.(*generateSeq[int, int]).limit; l != 0 {
+			t.Fatalf("generate: Expected an unbounded right half, but its limit is %d", l)
+		}
+		_, r = Filter(From[int](0, 1), even).Split(5)
+		if l := r.(*filterSeq[int]).limit; l != 0 {
+			t.Fatalf("filter: Expected an unbounded right half, but its limit is %d", l)
+		}
+		if e, ok := r.Elem(1000); !ok || e != 2010 {
+			t.Fatalf("filter: Expected r[1000] == 2010, but got %d", e)
+		}
+	})
+}
+
+// Regression test. A limited Repeatedly returned its element at every
+// index, Take could extend it past its limit, and Split at its limit
+// returned an unlimited copy on the left.
+func TestRepeatedlyLimit(t *testing.T) {
+	s := Repeatedly(7).Take(5)
+	if e, ok := s.Elem(4); !ok || e != 7 {
+		t.Fatalf("Expected s[4] == 7, but got %d", e)
+	}
+	if _, ok := s.Elem(5); ok {
+		t.Fatalf("Expected no element at index 5")
+	}
+	if n := len(ToSlice(s.Take(10))); n != 5 {
+		t.Fatalf("Expected Take to keep 5 elements, but got %d", n)
+	}
+	if n := len(ToSlice(s.Take(3))); n != 3 {
+		t.Fatalf("Expected Take to keep 3 elements, but got %d", n)
+	}
+	for _, at := range []uint64{5, 10} {
+		l, r := s.Split(at)
+		if n := len(ToSlice(l)); n != 5 {
+			t.Fatalf("Split(%d): Expected 5 elements on the left, but got %d", at, n)
+		}
+		if _, ok := r.Elem(0); ok {
+			t.Fatalf("Split(%d): Expected the right half to be empty", at)
+		}
+	}
+}
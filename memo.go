@@ -1,33 +1,201 @@
 package ion
 
 import (
+	"iter"
 	"math"
+	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 type memo[T any] struct {
+	// s holds the elements of the underlying Seq which have not yet
+	// been realized or handed out by Lazy. Once Lazy needs a thunk
+	// which is not realized, s is set to nil, and the remaining
+	// elements are pulled one at a time from s.Lazy with next.
 	s    Seq[T]
-	mems *Vec[T]
-	m    sync.Mutex
+	next func() (func() T, bool)
+	stop func()
+	// pending holds the elements following the realized prefix which
+	// have been handed out by Lazy, in order. They come before s.
+	pending []*memoCell[T]
+	// s, next and pending are guarded by r.m.
+	r realized[T]
 }
 
-func (m *memo[T]) Elem(i uint64) (T, bool) {
-	m.m.Lock()
-	defer m.m.Unlock()
-	len := m.mems.Len()
-	if i >= len {
-		need := i - len + 1
-		t, next := m.s.Split(uint64(need))
+// memoCell holds an element of a memo which has been handed out as a thunk
+// by Lazy. The thunk is only ever run once.
+type memoCell[T any] struct {
+	once  sync.Once
+	thunk func() T
+	v     T
+	done  atomic.Bool
+}
+
+func (c *memoCell[T]) get() T {
+	c.once.Do(func() {
+		c.v = c.thunk()
+		c.thunk = nil
+		c.done.Store(true)
+	})
+	return c.v
+}
+
+// pull returns a thunk for the next element of the underlying Seq, which
+// has not been realized or handed out. r.m must be held.
+func (m *memo[T]) pull() (func() T, bool) {
+	if m.next == nil {
+		m.next, m.stop = iter.Pull(iter.Seq[func() T](m.s.Lazy))
+		m.s = nil
+		// The underlying Lazy is paused until the next call to
+		// m.next, so stop it if the memo is abandoned.
+		runtime.SetFinalizer(m, func(m *memo[T]) { m.stop() })
+	}
+	return m.next()
+}
+
+// fill realizes up to `need` more elements, first from pending and then
+// from the underlying Seq, and returns true if the memo has ended.
+//
+// The thunks in pending may be running in other goroutines, and running
+// them calls user code, so fill never runs them. It stops at the first
+// which has not been run, and the caller must force it with force before
+// trying again.
+func (m *memo[T]) fill(need uint64, add func(T)) bool {
+	for ; need > 0 && len(m.pending) > 0; need-- {
+		if !m.pending[0].done.Load() {
+			return false
+		}
+		add(m.pending[0].v)
+		m.pending[0] = nil
+		m.pending = m.pending[1:]
+	}
+	if need == 0 {
+		return false
+	}
+	if m.next == nil {
+		t, next := m.s.Split(need)
 		m.s = next
-		news := BuildVec(func(add func(e T)) {
-			t.Iterate(func(e T) bool {
-				add(e)
-				return true
-			})
+		var n uint64
+		t.Iterate(func(e T) bool {
+			add(e)
+			n++
+			return true
 		})
-		m.mems = m.mems.Join(news)
+		return n < need
+	}
+	for ; need > 0; need-- {
+		e, ok := m.pull()
+		if !ok {
+			return true
+		}
+		add(e())
+	}
+	return false
+}
+
+// force runs the thunks handed out by Lazy for the elements before index
+// n which are not yet realized, without holding r.m.
+func (m *memo[T]) force(n uint64) {
+	m.r.m.Lock()
+	var cells []*memoCell[T]
+	if l := m.r.p.Load().len(); n > l {
+		cells = slices.Clone(m.pending[:min(n-l, uint64(len(m.pending)))])
+	}
+	m.r.m.Unlock()
+	for _, c := range cells {
+		c.get()
+	}
+}
+
+// realize makes sure at least n elements are realized, and returns the
+// realized prefix, like realized.extend.
+func (m *memo[T]) realize(n uint64) *prefix[T] {
+	for {
+		if p := m.r.p.Load(); p.len() >= n || p.ended() {
+			return p
+		}
+		m.force(n)
+		if p := m.r.extend(n, m.fill); p.len() >= n || p.ended() {
+			return p
+		}
+	}
+}
+
+// thunk returns a thunk for the element at index i. If the element is not
+// yet realized, the thunk computes and memoizes it when it is first run.
+// If the memo has no element i, thunk returns false.
+func (m *memo[T]) thunk(i uint64) (func() T, bool) {
+	if e, ok := m.r.p.Load().elem(i); ok {
+		return func() T { return e }, true
+	}
+	m.r.m.Lock()
+	defer m.r.m.Unlock()
+	p := m.r.p.Load()
+	if e, ok := p.elem(i); ok {
+		return func() T { return e }, true
+	}
+	j := i - p.len()
+	for j >= uint64(len(m.pending)) {
+		// Take thunks one at a time, so that nothing past element i
+		// is realized.
+		e, ok := m.pull()
+		if !ok {
+			return nil, false
+		}
+		m.pending = append(m.pending, &memoCell[T]{thunk: e})
+	}
+	c := m.pending[j]
+	return func() T {
+		e := c.get()
+		m.promote()
+		return e
+	}, true
+}
+
+// promote moves the computed elements at the front of pending into the
+// realized prefix.
+func (m *memo[T]) promote() {
+	m.r.m.Lock()
+	defer m.r.m.Unlock()
+	p, n := m.r.p.Load().grow(func(add func(T)) {
+		for _, c := range m.pending {
+			if !c.done.Load() {
+				return
+			}
+			add(c.v)
+		}
+	})
+	if n > 0 {
+		clear(m.pending[:n])
+		m.pending = m.pending[n:]
+		m.r.p.Store(p)
+	}
+}
+
+// lazy executes `f` over thunks for the elements from index i up to, but
+// not including, index `end`, until `f` returns false or the memo ends.
+func (m *memo[T]) lazy(i, end uint64, f func(func() T) bool) {
+	if !m.r.p.Load().iterate(i, func(e T) bool {
+		if i == end || !f(func() T { return e }) {
+			return false
+		}
+		i++
+		return true
+	}) {
+		return
+	}
+	for ; i < end; i++ {
+		e, ok := m.thunk(i)
+		if !ok || !f(e) {
+			return
+		}
 	}
-	return m.mems.Elem(i)
+}
+
+func (m *memo[T]) Elem(i uint64) (T, bool) {
+	return m.realize(i + 1).elem(i)
 }
 
 func (m *memo[T]) Split(n uint64) (Seq[T], Seq[T]) {
@@ -54,27 +222,11 @@ func (m *memo[T]) Take(n uint64) Seq[T] {
 }
 
 func (m *memo[T]) Iterate(f func(T) bool) {
-	for i := uint64(0); ; i++ { // TODO: This will only iterate up to math.MaxUint64 elements.
-		e, ok := m.Elem(i)
-		if !ok || !f(e) {
-			return
-		}
-	}
+	iterateRealized(0, math.MaxUint64, m.realize, f)
 }
 
 func (m *memo[T]) Lazy(f func(func() T) bool) {
-	quit := false
-	m.mems.Lazy(func(ef func() T) bool {
-		if !f(ef) {
-			quit = true
-			return false
-		}
-		return true
-	})
-	if quit {
-		return
-	}
-	m.s.Lazy(f) // TODO: This is a problem, because we're not memoizing the results.
+	m.lazy(0, math.MaxUint64, f)
 }
 
 var _ Seq[int] = &memoPart[int]{}
@@ -100,32 +252,11 @@ func (m *memoPart[T]) Elem(n uint64) (T, bool) {
 }
 
 func (m *memoPart[T]) Iterate(f func(T) bool) {
-	// TODO: This will only iterate up to math.MaxUint64 elements.
-	for i := m.lower; i < m.upper; i++ {
-		e, ok := m.underlying.Elem(i)
-		if !ok || !f(e) {
-			return
-		}
-	}
+	iterateRealized(m.lower, m.upper, m.underlying.realize, f)
 }
 
 func (m *memoPart[T]) Lazy(f func(func() T) bool) {
-	for i := m.lower; i < m.upper; i++ {
-		j := i
-		cont := f(func() T {
-			e, ok := m.underlying.Elem(j)
-			if !ok {
-				// TODO: This shouldn't happen, but indicates
-				// something wrong in the API.
-				var ret T
-				return ret
-			}
-			return e
-		})
-		if !cont {
-			return
-		}
-	}
+	m.underlying.lazy(m.lower, m.upper, f)
 }
 
 func (m *memoPart[T]) Split(n uint64) (Seq[T], Seq[T]) {
@@ -164,6 +295,8 @@ func (m *memoPart[T]) Take(n uint64) Seq[T] {
 // Keep in mind that Memo'd Seq's keep their values in memory, so Memo'ing
 // unbounded sequences can lead to unbounded memory usage if you use them
 // carelessly. Only Memo sequences when you have a specific reason to do so.
+// MemoWindow and MemoLRU are variants of Memo which bound the number of
+// elements kept.
 func Memo[T any](s Seq[T]) Seq[T] {
 	return &memo[T]{
 		s: s,
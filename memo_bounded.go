@@ -0,0 +1,301 @@
+package ion
+
+import (
+	"container/list"
+	"math"
+	"sync"
+)
+
+// MemoStats holds statistics about the cache of a Seq returned by
+// MemoWindow or MemoLRU. Elements are cached in chunks, and each call to
+// Elem, and each chunk visited by Iterate or Lazy, is one lookup.
+type MemoStats struct {
+	// Hits is the number of lookups served from the cache.
+	Hits uint64
+	// Misses is the number of lookups which had to compute a chunk.
+	Misses uint64
+	// Evictions is the number of chunks dropped from the cache.
+	Evictions uint64
+}
+
+// CachedSeq is a Seq which caches some of its elements, and reports
+// statistics about its cache. Seqs split or taken from a CachedSeq share
+// its cache, and also implement CachedSeq.
+type CachedSeq[T any] interface {
+	Seq[T]
+	// Stats returns the statistics of the cache.
+	Stats() MemoStats
+}
+
+// MemoWindow is like Memo, but only keeps approximately the `n` most
+// recently computed elements of `s`, for consumers which stream through a
+// Seq and never look back far. Elements are kept in chunks, so up to one
+// chunk more than `n` elements may be kept.
+//
+// Elements which have been dropped are recomputed from `s` when they are
+// accessed again, so `s` should support efficient Split. Recomputing
+// elements in order, as when streaming through the Seq again, continues
+// from the previously recomputed chunk rather than splitting `s` again.
+//
+// Bounding the memo only bounds the memory it uses itself. If `s` keeps
+// its own elements, as a StateGen does, they are all kept regardless.
+func MemoWindow[T any](s Seq[T], n uint64) CachedSeq[T] {
+	return newBoundedMemo(s, n, n)
+}
+
+// MemoLRU is like Memo, but keeps at most `capacity` elements of `s`,
+// rounded up to whole chunks. When the cache is full, the least recently
+// accessed chunk is evicted.
+//
+// Elements which have been evicted are recomputed from `s` when they are
+// accessed again, as with MemoWindow.
+func MemoLRU[T any](s Seq[T], capacity uint64) CachedSeq[T] {
+	return newBoundedMemo(s, capacity, 0)
+}
+
+type memoChunk[T any] struct {
+	c  uint64
+	es []T
+}
+
+// boundedMemo caches chunks of `size` elements of s. In window mode, only
+// chunks within `window` elements of the frontier are kept. Otherwise,
+// at most `max` chunks are kept and the least recently used is evicted.
+type boundedMemo[T any] struct {
+	m sync.Mutex
+	s Seq[T]
+	// next is s starting at chunk front, which is one past the last
+	// chunk computed in order.
+	next  Seq[T]
+	front uint64
+	// back is s starting at chunk backAt, and is used to recompute
+	// chunks before front which are not cached.
+	back   Seq[T]
+	backAt uint64
+	// end is the length of s, once it is known.
+	end   uint64
+	ended bool
+
+	size   uint64
+	max    int
+	window uint64
+	// order holds the cached chunks, most recently used first.
+	order  *list.List
+	chunks map[uint64]*list.Element
+	stats  MemoStats
+}
+
+func newBoundedMemo[T any](s Seq[T], capacity, window uint64) *boundedMemo[T] {
+	size := min(max(capacity, 1), spanSize)
+	return &boundedMemo[T]{
+		s:      s,
+		next:   s,
+		back:   s,
+		size:   size,
+		max:    int((max(capacity, 1) + size - 1) / size),
+		window: window,
+		order:  list.New(),
+		chunks: make(map[uint64]*list.Element),
+	}
+}
+
+// chunk returns the elements of chunk c, computing them if they are not
+// cached. m must be locked.
+func (m *boundedMemo[T]) chunk(c uint64) []T {
+	if e, ok := m.chunks[c]; ok {
+		m.stats.Hits++
+		m.order.MoveToFront(e)
+		return e.Value.(*memoChunk[T]).es
+	}
+	if m.ended && c*m.size >= m.end {
+		return nil
+	}
+	m.stats.Misses++
+
+	if c < m.front {
+		// Every chunk before front holds elements, so chunk c does.
+		if c < m.backAt {
+			m.back, m.backAt = m.s, 0
+		}
+		es, rest := m.compute(m.back, c-m.backAt)
+		m.back, m.backAt = rest, c+1
+		return m.keep(c, es)
+	}
+
+	es, rest := m.compute(m.next, c-m.front)
+	if len(es) == 0 {
+		// s ends at or before chunk c. If we jumped forward to reach
+		// chunk c, we don't know where, so leave front where it was and
+		// only record the end if we didn't.
+		if c == m.front {
+			m.ended = true
+			m.end = c * m.size
+		}
+		return nil
+	}
+	m.next, m.front = rest, c+1
+	if uint64(len(es)) < m.size {
+		m.ended = true
+		m.end = c*m.size + uint64(len(es))
+	}
+	return m.keep(c, es)
+}
+
+// compute returns the elements of the chunk `skip` chunks into s, and the
+// rest of s after that chunk.
+func (m *boundedMemo[T]) compute(s Seq[T], skip uint64) ([]T, Seq[T]) {
+	if skip > 0 {
+		_, s = s.Split(skip * m.size)
+	}
+	t, rest := s.Split(m.size)
+	es := make([]T, 0, m.size)
+	t.Iterate(func(e T) bool {
+		es = append(es, e)
+		return true
+	})
+	return es, rest
+}
+
+// keep caches the elements `es` of chunk c, if they belong in the cache,
+// and returns them. m must be locked.
+func (m *boundedMemo[T]) keep(c uint64, es []T) []T {
+	if m.window > 0 && m.outside(c) {
+		// A look back beyond the window. Don't keep it.
+		return es
+	}
+	m.chunks[c] = m.order.PushFront(&memoChunk[T]{c: c, es: es})
+	m.evict()
+	return es
+}
+
+// outside returns true if chunk c lies entirely outside the window.
+func (m *boundedMemo[T]) outside(c uint64) bool {
+	return (c+1)*m.size+m.window <= m.front*m.size
+}
+
+// evict drops chunks until the cache is within its bounds. m must be
+// locked.
+func (m *boundedMemo[T]) evict() {
+	if m.window > 0 {
+		for c, e := range m.chunks {
+			if m.outside(c) {
+				m.order.Remove(e)
+				delete(m.chunks, c)
+				m.stats.Evictions++
+			}
+		}
+		return
+	}
+	for m.order.Len() > m.max {
+		e := m.order.Back()
+		m.order.Remove(e)
+		delete(m.chunks, e.Value.(*memoChunk[T]).c)
+		m.stats.Evictions++
+	}
+}
+
+func (m *boundedMemo[T]) elem(i uint64) (T, bool) {
+	m.m.Lock()
+	es := m.chunk(i / m.size)
+	m.m.Unlock()
+	if j := i % m.size; j < uint64(len(es)) {
+		return es[j], true
+	}
+	var ret T
+	return ret, false
+}
+
+// iterate executes `f` over the elements from index i up to, but not
+// including, index `end`, until `f` returns false or the Seq ends.
+func (m *boundedMemo[T]) iterate(i, end uint64, f func(T) bool) {
+	for i < end {
+		m.m.Lock()
+		es := m.chunk(i / m.size)
+		m.m.Unlock()
+		j := i % m.size
+		if j >= uint64(len(es)) {
+			return
+		}
+		for _, e := range es[j:] {
+			if i == end || !f(e) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+func (m *boundedMemo[T]) Stats() MemoStats {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.stats
+}
+
+func (m *boundedMemo[T]) Elem(i uint64) (T, bool) {
+	return m.elem(i)
+}
+
+func (m *boundedMemo[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	return &boundedMemoPart[T]{m: m, lower: 0, upper: n},
+		&boundedMemoPart[T]{m: m, lower: n, upper: math.MaxUint64}
+}
+
+func (m *boundedMemo[T]) Take(n uint64) Seq[T] {
+	return &boundedMemoPart[T]{m: m, lower: 0, upper: n}
+}
+
+func (m *boundedMemo[T]) Iterate(f func(T) bool) {
+	m.iterate(0, math.MaxUint64, f)
+}
+
+func (m *boundedMemo[T]) Lazy(f func(func() T) bool) {
+	// Elements are computed a chunk at a time, in order, so there is
+	// nothing to gain from deferring the work.
+	m.iterate(0, math.MaxUint64, func(e T) bool {
+		return f(func() T { return e })
+	})
+}
+
+// boundedMemoPart is a part of a boundedMemo, like memoPart is for memo.
+type boundedMemoPart[T any] struct {
+	m     *boundedMemo[T]
+	lower uint64
+	upper uint64
+}
+
+func (p *boundedMemoPart[T]) Stats() MemoStats {
+	return p.m.Stats()
+}
+
+func (p *boundedMemoPart[T]) Elem(i uint64) (T, bool) {
+	if i >= p.upper-p.lower {
+		var ret T
+		return ret, false
+	}
+	return p.m.elem(p.lower + i)
+}
+
+func (p *boundedMemoPart[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	if n >= p.upper-p.lower {
+		return p, &boundedMemoPart[T]{m: p.m, lower: p.upper, upper: p.upper}
+	}
+	return &boundedMemoPart[T]{m: p.m, lower: p.lower, upper: p.lower + n},
+		&boundedMemoPart[T]{m: p.m, lower: p.lower + n, upper: p.upper}
+}
+
+func (p *boundedMemoPart[T]) Take(n uint64) Seq[T] {
+	if n >= p.upper-p.lower {
+		return p
+	}
+	return &boundedMemoPart[T]{m: p.m, lower: p.lower, upper: p.lower + n}
+}
+
+func (p *boundedMemoPart[T]) Iterate(f func(T) bool) {
+	p.m.iterate(p.lower, p.upper, f)
+}
+
+func (p *boundedMemoPart[T]) Lazy(f func(func() T) bool) {
+	p.m.iterate(p.lower, p.upper, func(e T) bool {
+		return f(func() T { return e })
+	})
+}
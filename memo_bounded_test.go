@@ -0,0 +1,219 @@
+package ion
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemoWindow(t *testing.T) {
+	var calls atomic.Int64
+	seq := MemoWindow(Map(From[int](0, 1), func(i int) int {
+		calls.Add(1)
+		return i * 2
+	}), 100)
+	m := seq.(*boundedMemo[int])
+
+	var n int
+	seq.Take(10000).Iterate(func(e int) bool {
+		if e != n*2 {
+			t.Fatalf("Expected %d, but got %d", n*2, e)
+		}
+		n++
+		if l := len(m.chunks); uint64(l)*m.size > 100+m.size {
+			t.Fatalf("Expected at most %d elements to be kept, but %d chunks of %d are", 100+m.size, l, m.size)
+		}
+		return true
+	})
+	// Elements are computed a chunk at a time.
+	if c := calls.Load(); c < 10000 || c > 10000+int64(m.size) {
+		t.Fatalf("Expected 10000 elements to be computed, but got %d", c)
+	}
+
+	// Recent elements are still cached.
+	before := seq.Stats()
+	if e, ok := seq.Elem(9990); !ok || e != 19980 {
+		t.Fatalf("Expected seq[9990] == 19980, but got %d", e)
+	}
+	if s := seq.Stats(); s.Hits != before.Hits+1 || s.Misses != before.Misses {
+		t.Fatalf("Expected a hit, but got %+v after %+v", s, before)
+	}
+
+	// Looking back beyond the window recomputes the element.
+	if e, ok := seq.Elem(5); !ok || e != 10 {
+		t.Fatalf("Expected seq[5] == 10, but got %d", e)
+	}
+	if s := seq.Stats(); s.Misses != before.Misses+1 || s.Evictions == 0 {
+		t.Fatalf("Expected a miss and some evictions, but got %+v", s)
+	}
+	testFinSeq(t, MemoWindow(From[int](0, 1), 10).Take(10000))
+}
+
+func TestMemoLRU(t *testing.T) {
+	var calls atomic.Int64
+	seq := MemoLRU(Map(From[int](0, 1), func(i int) int {
+		calls.Add(1)
+		return i * 2
+	}), 4*spanSize)
+	m := seq.(*boundedMemo[int])
+
+	// Four chunks fit in the cache.
+	for r := 0; r < 10; r++ {
+		for i := uint64(0); i < 4*spanSize; i += 7 {
+			if e, ok := seq.Elem(i); !ok || e != int(i*2) {
+				t.Fatalf("Expected seq[%d] == %d, but got %d", i, i*2, e)
+			}
+		}
+	}
+	if c := calls.Load(); c != 4*spanSize {
+		t.Fatalf("Expected %d elements to be computed, but got %d", 4*spanSize, c)
+	}
+	if s := seq.Stats(); s.Misses != 4 || s.Evictions != 0 {
+		t.Fatalf("Expected 4 misses and no evictions, but got %+v", s)
+	}
+
+	// Touch chunk 0, so chunk 1 is the least recently used.
+	seq.Elem(0)
+	seq.Elem(4 * spanSize)
+	if _, ok := m.chunks[1]; ok || len(m.chunks) != 4 {
+		t.Fatalf("Expected chunk 1 to be evicted")
+	}
+	if _, ok := m.chunks[0]; !ok {
+		t.Fatalf("Expected chunk 0 to be kept")
+	}
+
+	for i := 0; i < 10000; i++ {
+		k := uint64(rand.Intn(100000))
+		if e, ok := seq.Elem(k); !ok || e != int(k*2) {
+			t.Fatalf("Expected seq[%d] == %d, but got %d", k, k*2, e)
+		}
+		if len(m.chunks) > 4 {
+			t.Fatalf("Expected at most 4 chunks, but got %d", len(m.chunks))
+		}
+	}
+	s := seq.Stats()
+	if s.Hits+s.Misses != 10000+10*((4*spanSize+6)/7)+2 || s.Evictions != s.Misses-4 {
+		t.Fatalf("Unexpected stats %+v", s)
+	}
+	testFinSeq(t, MemoLRU(From[int](0, 1), 1000).Take(10000))
+}
+
+func TestMemoBoundedEnd(t *testing.T) {
+	for _, seq := range []CachedSeq[int]{
+		MemoWindow(From[int](0, 1).Take(100), 10),
+		MemoLRU(From[int](0, 1).Take(100), 10),
+	} {
+		if _, ok := seq.Elem(1000); ok {
+			t.Fatalf("Expected no element at index 1000")
+		}
+		if e, ok := seq.Elem(99); !ok || e != 99 {
+			t.Fatalf("Expected seq[99] == 99, but got %d", e)
+		}
+		if _, ok := seq.Elem(100); ok {
+			t.Fatalf("Expected no element at index 100")
+		}
+		_, r := seq.Split(90)
+		if n := len(ToSlice(r)); n != 10 {
+			t.Fatalf("Expected 10 elements, but got %d", n)
+		}
+		l, _ := seq.Split(0)
+		rl, rr := r.Split(20)
+		for i, part := range []Seq[int]{r, l, seq.Take(0), r.Take(0), rl, rr} {
+			if _, ok := part.(CachedSeq[int]); !ok {
+				t.Fatalf("Expected part %d of a CachedSeq to be a CachedSeq", i)
+			}
+		}
+		for i, part := range []Seq[int]{l, seq.Take(0), r.Take(0), rr} {
+			if n := len(ToSlice(part)); n != 0 {
+				t.Fatalf("Expected part %d to be empty, but it has %d elements", i, n)
+			}
+		}
+	}
+}
+
+func TestMemoBoundedJumpPastEnd(t *testing.T) {
+	seq := MemoLRU(From[int](0, 1).Take(100), 10)
+	m := seq.(*boundedMemo[int])
+
+	// Jumping past the end must neither cache an empty chunk, nor
+	// record the wrong length.
+	if _, ok := seq.Elem(1000); ok {
+		t.Fatalf("Expected no element at index 1000")
+	}
+	if len(m.chunks) != 0 {
+		t.Fatalf("Expected no chunks to be cached, but %d are", len(m.chunks))
+	}
+	if e, ok := seq.Elem(50); !ok || e != 50 {
+		t.Fatalf("Expected seq[50] == 50, but got %d", e)
+	}
+	if n := len(ToSlice(seq)); n != 100 {
+		t.Fatalf("Expected 100 elements, but got %d", n)
+	}
+	if !m.ended || m.end != 100 {
+		t.Fatalf("Expected the length to be 100, but got %d, %t", m.end, m.ended)
+	}
+}
+
+func TestMemoWindowRestream(t *testing.T) {
+	// Splitting a Filter tests every element before the split point, so
+	// recomputing each chunk by splitting from the start would be
+	// quadratic.
+	var calls atomic.Int64
+	seq := MemoWindow(Filter(From[int](0, 1), func(i int) bool {
+		calls.Add(1)
+		return true
+	}), 100)
+	const n = 10000
+	for pass := 0; pass < 2; pass++ {
+		var i int
+		seq.Take(n).Iterate(func(e int) bool {
+			if e != i {
+				t.Fatalf("Expected %d, but got %d", i, e)
+			}
+			i++
+			return true
+		})
+		if i != n {
+			t.Fatalf("Expected %d elements, but got %d", n, i)
+		}
+	}
+	if c := calls.Load(); c > 3*n {
+		t.Fatalf("Expected streaming twice to test about %d elements, but it tested %d", 2*n, c)
+	}
+}
+
+func TestMemoBoundedConcurrent(t *testing.T) {
+	for _, seq := range []CachedSeq[int]{
+		MemoWindow(Map(From[int](0, 1), func(i int) int { return i * 2 }), 200),
+		MemoLRU(Map(From[int](0, 1), func(i int) int { return i * 2 }), 500),
+	} {
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				if g%2 == 0 {
+					n := 0
+					seq.Take(20000).Iterate(func(e int) bool {
+						if e != n*2 {
+							t.Errorf("Expected %d, but got %d", n*2, e)
+							return false
+						}
+						n++
+						return true
+					})
+					return
+				}
+				for i := 0; i < 2000; i++ {
+					k := uint64(rand.Intn(20000))
+					if e, ok := seq.Elem(k); !ok || e != int(k*2) {
+						t.Errorf("Expected seq[%d] == %d, but got %d", k, k*2, e)
+						return
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,179 @@
+package ion
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoLazy(t *testing.T) {
+	const n = 5000
+	var calls [n + spanSize]atomic.Int32
+	seq := Memo(Map(From[int](0, 1), func(i int) int {
+		calls[i].Add(1)
+		return i * 3
+	}))
+
+	var thunks []func() int
+	seq.Take(n).Lazy(func(e func() int) bool {
+		thunks = append(thunks, e)
+		return true
+	})
+	if len(thunks) != n {
+		t.Fatalf("Expected %d thunks, but got %d", n, len(thunks))
+	}
+	for i := range calls {
+		if c := calls[i].Load(); c != 0 {
+			t.Fatalf("Expected Lazy not to compute any elements, but element %d was computed %d times", i, c)
+		}
+	}
+
+	// Force every thunk several times, from many goroutines, in random
+	// order.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, i := range rand.Perm(n) {
+				if e := thunks[i](); e != i*3 {
+					t.Errorf("Expected thunk %d to return %d, but got %d", i, i*3, e)
+					return
+				}
+			}
+		}()
+	}
+	// Elem must agree with the thunks while they are being forced.
+	for i := uint64(0); i < n; i += 17 {
+		if e, ok := seq.Elem(i); !ok || e != int(i*3) {
+			t.Errorf("Expected seq[%d] == %d, but got %d", i, i*3, e)
+		}
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if c := calls[i].Load(); c != 1 {
+			t.Fatalf("Expected element %d to be computed once, but it was computed %d times", i, c)
+		}
+	}
+	if l := seq.(*memo[int]).r.p.Load().len(); l < n {
+		t.Fatalf("Expected the forced elements to be stored, but only %d were", l)
+	}
+
+	// Everything is memoized now.
+	var sum int
+	seq.Take(n).Iterate(func(e int) bool {
+		sum += e
+		return true
+	})
+	if sum != 3*(n-1)*n/2 {
+		t.Fatalf("Expected sum of %d, but got %d", 3*(n-1)*n/2, sum)
+	}
+	for i := 0; i < n; i++ {
+		if c := calls[i].Load(); c != 1 {
+			t.Fatalf("Expected element %d to be computed once, but it was computed %d times", i, c)
+		}
+	}
+}
+
+func TestMemoLazyParts(t *testing.T) {
+	var calls atomic.Int64
+	seq := Memo(Map(From[int](0, 1), func(i int) int {
+		calls.Add(1)
+		return i
+	}))
+	l, r := seq.Split(1000)
+	rl, _ := r.Split(1000)
+
+	var wg sync.WaitGroup
+	var sum atomic.Int64
+	for _, part := range []Seq[int]{rl, l} {
+		part.Lazy(func(e func() int) bool {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sum.Add(int64(e()))
+			}()
+			return true
+		})
+	}
+	wg.Wait()
+	if s := sum.Load(); s != 1999*2000/2 {
+		t.Fatalf("Expected sum of %d, but got %d", 1999*2000/2, s)
+	}
+	if c := calls.Load(); c != 2000 {
+		t.Fatalf("Expected 2000 elements to be computed, but got %d", c)
+	}
+	testFinSeq(t, seq.Take(10000))
+}
+
+func TestMemoLazyPullsOne(t *testing.T) {
+	var calls int
+	seq := Memo(Map(StateGen(func() (int, bool) {
+		calls++
+		return calls, true
+	}), func(i int) int {
+		return i * 2
+	}))
+
+	// Handing out the first thunk must not realize anything after it.
+	var first func() int
+	seq.Lazy(func(e func() int) bool {
+		first = e
+		return false
+	})
+	if calls != 1 {
+		t.Fatalf("Expected 1 call to the generator, but got %d", calls)
+	}
+	if e := first(); e != 2 {
+		t.Fatalf("Expected 2, but got %d", e)
+	}
+	if e, ok := seq.Elem(2); !ok || e != 6 {
+		t.Fatalf("Expected seq[2] == 6, but got %d", e)
+	}
+	if calls != 3 {
+		t.Fatalf("Expected 3 calls to the generator, but got %d", calls)
+	}
+	testInfSeq(t, Map(seq, func(i int) int { return i/2 - 1 }))
+}
+
+func TestMemoSlowThunk(t *testing.T) {
+	release := make(chan struct{})
+	seq := Memo(Map(From[int](0, 1), func(i int) int {
+		if i == 1 {
+			<-release
+		}
+		return i
+	}))
+	var thunks []func() int
+	seq.Take(2).Lazy(func(e func() int) bool {
+		thunks = append(thunks, e)
+		return true
+	})
+
+	// One goroutine is stuck running thunk 1, and another is waiting
+	// for it. Neither may block readers of other elements.
+	go thunks[1]()
+	go seq.Elem(5)
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan int)
+	go func() {
+		e, _ := seq.Elem(0)
+		done <- e
+	}()
+	select {
+	case e := <-done:
+		if e != 0 {
+			t.Fatalf("Expected seq[0] == 0, but got %d", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out. A slow thunk blocked another reader.")
+	}
+	close(release)
+	if e, ok := seq.Elem(5); !ok || e != 5 {
+		t.Fatalf("Expected seq[5] == 5, but got %d", e)
+	}
+}
@@ -0,0 +1,188 @@
+package ion
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+type parJob[T, U any] struct {
+	e   func() T
+	res chan U
+}
+
+// ParMap is like Map, but executes `f` on a pool of `workers` goroutines.
+// The elements of `s` are retrieved with Lazy, so that the workers also
+// incur the cost of realizing the elements of `s` where `s` supports it.
+// The resulting Seq[U] contains the results in the same order as the
+// elements of `s` they were produced from.
+//
+// Work does not begin until the first element of the resulting Seq is
+// requested. After that, the workers run ahead of the consumer by up to
+// 2 * `workers` elements. Use ParMapBuffered to control this.
+//
+// If `workers` is <= 0, runtime.GOMAXPROCS(0) workers are used.
+//
+// Unlike Map, the results of `f` are retained, as with StateGen, so `f`
+// is called exactly once on each element that is processed.
+//
+// The resulting Seq ends when `s` ends or `ctx` is done. Once `ctx` is
+// done, no more elements are produced, even if their results are ready.
+// If the resulting Seq is not iterated to the end, `ctx` must be cancelled
+// in order to release the worker goroutines.
+func ParMap[T, U any](ctx context.Context, s Seq[T], workers int, f func(T) U) Seq[U] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return ParMapBuffered(ctx, s, workers, 2*workers, f)
+}
+
+// ParMapBuffered is like ParMap, but allows specifying the size of the
+// reorder buffer. At most `buffer` elements will be processed ahead of
+// the element the consumer is waiting on. A larger buffer allows the
+// workers to continue while a slow element is being processed, at the
+// cost of holding more results in memory.
+//
+// If `buffer` is < `workers`, some of the workers will sit idle.
+func ParMapBuffered[T, U any](ctx context.Context, s Seq[T], workers, buffer int, f func(T) U) Seq[U] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if buffer <= 0 {
+		buffer = 1
+	}
+
+	var order chan chan U
+	start := func() {
+		jobs := make(chan parJob[T, U])
+		order = make(chan chan U, buffer)
+		for i := 0; i < workers; i++ {
+			go func() {
+				for j := range jobs {
+					j.res <- f(j.e())
+				}
+			}()
+		}
+		go func() {
+			defer close(jobs)
+			defer close(order)
+			s.Lazy(func(e func() T) bool {
+				j := parJob[T, U]{e: e, res: make(chan U, 1)}
+				// Reserve a place in the reorder buffer before
+				// handing out the job.
+				select {
+				case order <- j.res:
+				case <-ctx.Done():
+					return false
+				}
+				select {
+				case jobs <- j:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		}()
+	}
+
+	var started bool
+	return StateGen(func() (U, bool) {
+		// StateGen serializes calls to this func, so we don't
+		// need to synchronize here.
+		if !started {
+			started = true
+			start()
+		}
+		var ret U
+		var res chan U
+		var ok bool
+		select {
+		case res, ok = <-order:
+			if !ok {
+				return ret, false
+			}
+		case <-ctx.Done():
+			return ret, false
+		}
+		select {
+		case ret = <-res:
+		case <-ctx.Done():
+			return ret, false
+		}
+		// If ctx is done, select picks at random between it and a
+		// ready result, so check it again. Once ctx is cancelled, no
+		// more results are returned.
+		if ctx.Err() != nil {
+			var zero U
+			return zero, false
+		}
+		return ret, true
+	})
+}
+
+// ParMapUnordered is like ParMap, but the results are yielded in the order
+// they are completed, rather than the order of the elements of `s`. This
+// allows the workers to continue as long as there are elements available,
+// regardless of how long any individual element takes to process.
+func ParMapUnordered[T, U any](ctx context.Context, s Seq[T], workers int, f func(T) U) Seq[U] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var results chan U
+	start := func() {
+		jobs := make(chan func() T)
+		results = make(chan U, workers)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				for e := range jobs {
+					select {
+					case results <- f(e()):
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+		go func() {
+			defer close(jobs)
+			s.Lazy(func(e func() T) bool {
+				select {
+				case jobs <- e:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		}()
+	}
+
+	var started bool
+	return StateGen(func() (U, bool) {
+		if !started {
+			started = true
+			start()
+		}
+		var ret U
+		var ok bool
+		select {
+		case ret, ok = <-results:
+		case <-ctx.Done():
+			return ret, false
+		}
+		// As in ParMapBuffered, make sure no more results are returned
+		// once ctx is cancelled.
+		if !ok || ctx.Err() != nil {
+			var zero U
+			return zero, false
+		}
+		return ret, true
+	})
+}
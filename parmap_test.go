@@ -0,0 +1,122 @@
+package ion
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParMap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, ParMap(ctx, From[int](0, 1), 4, func(i int) int { return i }))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, ParMap(ctx, From[int](1, 1).Take(10000), 4, func(i int) int { return i - 1 }))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		testFinSeq(t, ParMapBuffered(ctx, From[int](0, 1).Take(10000), 8, 1, func(i int) int { return i }))
+	})
+}
+
+func TestParMapConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var running, most int32
+	s := ParMap(ctx, From[int](0, 1).Take(100), 4, func(i int) int {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+		// Later elements finish first.
+		time.Sleep(time.Duration(100-i) * 50 * time.Microsecond)
+		atomic.AddInt32(&running, -1)
+		return i * 2
+	})
+
+	var n int
+	s.Iterate(func(e int) bool {
+		if e != n*2 {
+			t.Fatalf("Expected s[%d] == %d, but was %d", n, n*2, e)
+		}
+		n++
+		return true
+	})
+	if n != 100 {
+		t.Fatalf("Expected 100 elements, but got %d", n)
+	}
+	if most < 2 || most > 4 {
+		t.Fatalf("Expected between 2 and 4 concurrent workers, but saw %d", most)
+	}
+}
+
+func TestParMapCancel(t *testing.T) {
+	for _, name := range []string{"ParMap", "ParMapBuffered", "ParMapUnordered"} {
+		// Results are ready ahead of the consumer, so run several
+		// times to catch a select which picks them over cancellation.
+		for r := 0; r < 20; r++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			var s Seq[int]
+			switch name {
+			case "ParMap":
+				s = ParMap(ctx, From[int](0, 1), 4, func(i int) int { return i })
+			case "ParMapBuffered":
+				s = ParMapBuffered(ctx, From[int](0, 1), 4, 64, func(i int) int { return i })
+			default:
+				s = ParMapUnordered(ctx, From[int](0, 1), 4, func(i int) int { return i })
+			}
+
+			done := make(chan int)
+			go func() {
+				var n int
+				s.Iterate(func(e int) bool {
+					n++
+					if n == 1000 {
+						cancel()
+					}
+					return true
+				})
+				done <- n
+			}()
+
+			select {
+			case n := <-done:
+				if n != 1000 {
+					t.Fatalf("%s: Expected output to stop at the cancellation after 1000 elements, but got %d", name, n)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: Timed out.", name)
+			}
+		}
+	}
+}
+
+func TestParMapUnordered(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := ParMapUnordered(ctx, From[int](0, 1).Take(10000), 4, func(i int) int { return i * 2 })
+	res := ToSlice(s)
+	slices.Sort(res)
+	if len(res) != 10000 {
+		t.Fatalf("Expected 10000 elements, but got %d", len(res))
+	}
+	for i, e := range res {
+		if e != i*2 {
+			t.Fatalf("Expected res[%d] == %d, but was %d", i, i*2, e)
+		}
+	}
+
+	// The results are retained.
+	if l := len(ToSlice(s)); l != 10000 {
+		t.Fatalf("Expected 10000 elements, but got %d", l)
+	}
+}
@@ -0,0 +1,92 @@
+package ion
+
+import (
+	"cmp"
+)
+
+// Vecs smaller than this are reduced sequentially by ParReduce.
+const parReduceThreshold = 16 * spanSize
+
+// Monoid describes an associative operation Combine over values of type T,
+// along with its identity value. Identity must satisfy
+//
+//	Combine(Identity, x) == Combine(x, Identity) == x
+//
+// for all x.
+type Monoid[T any] struct {
+	Identity T
+	Combine  func(T, T) T
+}
+
+// SumMonoid returns a Monoid which sums numbers.
+func SumMonoid[T Number]() Monoid[T] {
+	return Monoid[T]{
+		Combine: func(a, b T) T { return a + b },
+	}
+}
+
+// MinMonoid returns a Monoid which finds the minimum of values.
+// `identity` must be greater than or equal to every value being
+// combined, e.g. math.MaxInt.
+func MinMonoid[T cmp.Ordered](identity T) Monoid[T] {
+	return Monoid[T]{
+		Identity: identity,
+		Combine:  func(a, b T) T { return min(a, b) },
+	}
+}
+
+// MaxMonoid returns a Monoid which finds the maximum of values.
+// `identity` must be less than or equal to every value being
+// combined, e.g. math.MinInt.
+func MaxMonoid[T cmp.Ordered](identity T) Monoid[T] {
+	return Monoid[T]{
+		Identity: identity,
+		Combine:  func(a, b T) T { return max(a, b) },
+	}
+}
+
+// ConcatMonoid returns a Monoid which concatenates Vecs.
+func ConcatMonoid[T any]() Monoid[*Vec[T]] {
+	return Monoid[*Vec[T]]{
+		Combine: func(a, b *Vec[T]) *Vec[T] { return a.Join(b) },
+	}
+}
+
+// ParReduce reduces the Vec `v` to a single value of type U. It applies
+// `mapf` to every element of `v`, and combines the results with `combine`,
+// starting from `identity`.
+//
+// ParReduce splits the work along the internal tree structure of `v`, reducing
+// subtrees on separate goroutines and combining their results. This means
+// `combine` must be associative, and `identity` must be an identity value
+// for `combine` (See Monoid). `combine` need not be commutative, as the results
+// of subtrees are always combined in order. Both `mapf` and `combine` must be
+// safe to call concurrently.
+//
+// For example, to sum the squares of the elements of v:
+//
+//	m := SumMonoid[int]()
+//	sum := ParReduce(v, m.Identity, func(i int) int { return i * i }, m.Combine)
+func ParReduce[T, U any](v *Vec[T], identity U, mapf func(T) U, combine func(U, U) U) U {
+	if v == nil {
+		return identity
+	}
+	l, ok := v.l.(*Vec[T])
+	if !ok || v.Len() <= parReduceThreshold {
+		acc := identity
+		v.iterate(func(e T) bool {
+			acc = combine(acc, mapf(e))
+			return true
+		})
+		return acc
+	}
+
+	r, _ := v.r.(*Vec[T])
+
+	lc := make(chan U, 1)
+	go func() {
+		lc <- ParReduce(l, identity, mapf, combine)
+	}()
+	ru := ParReduce(r, identity, mapf, combine)
+	return combine(<-lc, ru)
+}
@@ -0,0 +1,59 @@
+package ion
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParReduce(t *testing.T) {
+	v := BuildVec(func(add func(int)) {
+		for i := 0; i < 100000; i++ {
+			add(i)
+		}
+	})
+
+	t.Run("sum", func(t *testing.T) {
+		m := SumMonoid[int]()
+		if s := ParReduce(v, m.Identity, func(i int) int { return i }, m.Combine); s != 4999950000 {
+			t.Fatalf("Expected sum == 4999950000, but was %d", s)
+		}
+	})
+	t.Run("min-max", func(t *testing.T) {
+		id := func(i int) int { return i }
+		mn := MinMonoid(math.MaxInt)
+		mx := MaxMonoid(math.MinInt)
+		if e := ParReduce(v, mn.Identity, id, mn.Combine); e != 0 {
+			t.Fatalf("Expected min == 0, but was %d", e)
+		}
+		if e := ParReduce(v, mx.Identity, id, mx.Combine); e != 99999 {
+			t.Fatalf("Expected max == 99999, but was %d", e)
+		}
+	})
+	t.Run("concat", func(t *testing.T) {
+		// Concatenation is not commutative, so this checks results are
+		// combined in order.
+		m := ConcatMonoid[int]()
+		v, _ := v.split(5000)
+		c := ParReduce(v, m.Identity, func(i int) *Vec[int] {
+			return (*Vec[int])(nil).Append(i * 2)
+		}, m.Combine)
+		if c.Len() != 5000 {
+			t.Fatalf("Expected 5000 elements, but got %d", c.Len())
+		}
+		for i, e := range c.All() {
+			if e != int(i)*2 {
+				t.Fatalf("Expected c[%d] == %d, but was %d", i, i*2, e)
+			}
+		}
+	})
+	t.Run("small", func(t *testing.T) {
+		m := SumMonoid[int]()
+		if s := ParReduce((*Vec[int])(nil), m.Identity, func(i int) int { return i }, m.Combine); s != 0 {
+			t.Fatalf("Expected sum == 0, but was %d", s)
+		}
+		small, _ := v.split(100)
+		if s := ParReduce(small, m.Identity, func(i int) int { return i }, m.Combine); s != 4950 {
+			t.Fatalf("Expected sum == 4950, but was %d", s)
+		}
+	})
+}
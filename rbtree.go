@@ -14,11 +14,12 @@ import (
 // original, meaning operations can be performed efficiently without
 // needing to reconstruct an entirely new tree for every operation.
 type RBTree[T cmp.Ordered, U any] struct {
-	c color
-	k T
-	v U
-	l *RBTree[T, U]
-	r *RBTree[T, U]
+	c     color
+	count uint64
+	k     T
+	v     U
+	l     *RBTree[T, U]
+	r     *RBTree[T, U]
 }
 
 // Insert returns a new tree, consisting of the original tree with the
@@ -57,11 +58,12 @@ func (r *RBTree[T, U]) Dot(w io.Writer) {
 }
 
 // Size returns the number of elements present in the tree.
+// Size runs in constant time.
 func (r *RBTree[T, U]) Size() uint64 {
 	if r == nil {
 		return 0
 	}
-	return r.l.Size() + r.r.Size() + 1
+	return r.count
 }
 
 func (r *RBTree[T, U]) rdot(w io.Writer, m map[T]struct{}) {
@@ -102,18 +104,20 @@ func (r *RBTree[T, U]) right_rotate() *RBTree[T, U] {
 	//x := r.l
 
 	y := &RBTree[T, U]{
-		k: r.k,
-		v: r.v,
-		c: r.c,
-		l: r.l,
-		r: r.r,
+		k:     r.k,
+		v:     r.v,
+		c:     r.c,
+		count: r.count,
+		l:     r.l,
+		r:     r.r,
 	}
 	x := &RBTree[T, U]{
-		k: r.l.k,
-		v: r.l.v,
-		c: r.l.c,
-		l: r.l.l,
-		r: r.l.r,
+		k:     r.l.k,
+		v:     r.l.v,
+		c:     r.l.c,
+		count: r.l.count,
+		l:     r.l.l,
+		r:     r.l.r,
 	}
 
 	a := r.l.l
@@ -124,6 +128,8 @@ func (r *RBTree[T, U]) right_rotate() *RBTree[T, U] {
 	x.r = y
 	y.l = b
 	y.r = c
+	y.count = y.l.Size() + y.r.Size() + 1
+	x.count = x.l.Size() + y.count + 1
 	return x
 }
 
@@ -138,18 +144,20 @@ func (r *RBTree[T, U]) left_rotate() *RBTree[T, U] {
 	//y := r.r
 
 	x := &RBTree[T, U]{
-		k: r.k,
-		v: r.v,
-		c: r.c,
-		l: r.l,
-		r: r.r,
+		k:     r.k,
+		v:     r.v,
+		c:     r.c,
+		count: r.count,
+		l:     r.l,
+		r:     r.r,
 	}
 	y := &RBTree[T, U]{
-		k: r.r.k,
-		v: r.r.v,
-		c: r.r.c,
-		l: r.r.l,
-		r: r.r.r,
+		k:     r.r.k,
+		v:     r.r.v,
+		c:     r.r.c,
+		count: r.r.count,
+		l:     r.r.l,
+		r:     r.r.r,
 	}
 
 	a := r.l
@@ -160,6 +168,8 @@ func (r *RBTree[T, U]) left_rotate() *RBTree[T, U] {
 	y.r = c
 	x.l = a
 	x.r = b
+	x.count = x.l.Size() + x.r.Size() + 1
+	y.count = x.count + y.r.Size() + 1
 	return y
 }
 
@@ -234,11 +244,12 @@ func rebalance[T cmp.Ordered, U any](chain []*RBTree[T, U]) *RBTree[T, U] {
 			u = g.r
 			if u != nil {
 				u = &RBTree[T, U]{
-					k: u.k,
-					v: u.v,
-					c: u.c,
-					l: u.l,
-					r: u.r,
+					k:     u.k,
+					v:     u.v,
+					c:     u.c,
+					count: u.count,
+					l:     u.l,
+					r:     u.r,
 				}
 				g.r = u
 			}
@@ -246,11 +257,12 @@ func rebalance[T cmp.Ordered, U any](chain []*RBTree[T, U]) *RBTree[T, U] {
 			u = g.l
 			if u != nil {
 				u = &RBTree[T, U]{
-					k: u.k,
-					v: u.v,
-					c: u.c,
-					l: u.l,
-					r: u.r,
+					k:     u.k,
+					v:     u.v,
+					c:     u.c,
+					count: u.count,
+					l:     u.l,
+					r:     u.r,
 				}
 				g.l = u
 			}
@@ -335,22 +347,24 @@ func rebalance[T cmp.Ordered, U any](chain []*RBTree[T, U]) *RBTree[T, U] {
 func rechain[T cmp.Ordered, U any](chain []*RBTree[T, U]) []*RBTree[T, U] {
 	nc := make([]*RBTree[T, U], len(chain))
 	prev := &RBTree[T, U]{
-		k: chain[0].k,
-		v: chain[0].v,
-		c: chain[0].c,
-		l: chain[0].l,
-		r: chain[0].r,
+		k:     chain[0].k,
+		v:     chain[0].v,
+		c:     chain[0].c,
+		count: chain[0].count,
+		l:     chain[0].l,
+		r:     chain[0].r,
 	}
 	nc[0] = prev
 	for i := 1; i < len(chain); i++ {
 		t := chain[i]
 
 		nt := &RBTree[T, U]{
-			k: t.k,
-			v: t.v,
-			c: t.c,
-			l: t.l,
-			r: t.r,
+			k:     t.k,
+			v:     t.v,
+			c:     t.c,
+			count: t.count,
+			l:     t.l,
+			r:     t.r,
 		}
 		if prev.l == t {
 			prev.l = nt
@@ -369,37 +383,110 @@ func rechain[T cmp.Ordered, U any](chain []*RBTree[T, U]) []*RBTree[T, U] {
 	return nc
 }
 
+// grow increments the size of every node in the chain. The chain must
+// already have been copied with rechain.
+func grow[T cmp.Ordered, U any](chain []*RBTree[T, U]) {
+	for _, t := range chain {
+		t.count++
+	}
+}
+
+// shrink decrements the size of every node in the chain except the last,
+// which is about to be removed. The chain must already have been copied
+// with rechain.
+func shrink[T cmp.Ordered, U any](chain []*RBTree[T, U]) {
+	for _, t := range chain[:len(chain)-1] {
+		t.count--
+	}
+}
+
 func (r *RBTree[T, U]) tree_insert(k T, v U, chain []*RBTree[T, U]) *RBTree[T, U] {
 	if r == nil {
-		return &RBTree[T, U]{k: k, v: v}
+		return &RBTree[T, U]{k: k, v: v, count: 1}
 	}
 	chain = append(chain, r)
 	switch {
 	case r.k == k:
-		r := duplicate(r)
-		r.v = v
-		return r
+		chain = rechain(chain)
+		chain[len(chain)-1].v = v
+		return chain[0]
 	case r.k < k:
 		if r.r == nil {
-			chain = rechain(chain)
-			r = chain[len(chain)-1]
-			r.r = &RBTree[T, U]{k: k, v: v}
-			return rebalance(append(chain, r.r))
+			return insertAt(chain, k, v)
 		}
 		return r.r.tree_insert(k, v, chain)
 
 	case r.k > k:
 		if r.l == nil {
-			chain = rechain(chain)
-			r = chain[len(chain)-1]
-			r.l = &RBTree[T, U]{k: k, v: v}
-			return rebalance(append(chain, r.l))
+			return insertAt(chain, k, v)
 		}
 		return r.l.tree_insert(k, v, chain)
 	}
 	panic("Not possible.")
 }
 
+// Update returns a new tree in which the value for key `k` has been
+// replaced by the result of `f`. `f` is called with the current value of
+// `k` and whether `k` is present. If `f` returns true, its value is
+// inserted or replaces the current one. If it returns false, `k` is
+// removed. Update walks the tree once, and returns a boolean indicating
+// whether or not the tree was changed. If nothing changed, it returns `r`.
+func (r *RBTree[T, U]) Update(k T, f func(old U, ok bool) (U, bool)) (*RBTree[T, U], bool) {
+	if r == nil {
+		var zero U
+		v, keep := f(zero, false)
+		if !keep {
+			return nil, false
+		}
+		return &RBTree[T, U]{k: k, v: v, count: 1}, true
+	}
+	var buf [64]*RBTree[T, U]
+	chain := buf[:0]
+	for n := r; ; {
+		chain = append(chain, n)
+		var next *RBTree[T, U]
+		switch {
+		case n.k == k:
+			v, keep := f(n.v, true)
+			if !keep {
+				return deleteAt(chain), true
+			}
+			chain = rechain(chain)
+			chain[len(chain)-1].v = v
+			return chain[0], true
+		case n.k < k:
+			next = n.r
+		case n.k > k:
+			next = n.l
+		}
+		if next == nil {
+			var zero U
+			v, keep := f(zero, false)
+			if !keep {
+				return r, false
+			}
+			return insertAt(chain, k, v), true
+		}
+		n = next
+	}
+}
+
+// insertAt adds `k`/`v` as a new child of the last node in chain, which
+// must have no child on the side `k` belongs, and returns the new root.
+// chain is the path from the root to the node.
+func insertAt[T cmp.Ordered, U any](chain []*RBTree[T, U], k T, v U) *RBTree[T, U] {
+	chain = rechain(chain)
+	grow(chain)
+	r := chain[len(chain)-1]
+	n := &RBTree[T, U]{k: k, v: v, count: 1}
+	if r.k < k {
+		r.r = n
+	} else {
+		r.l = n
+	}
+	return rebalance(append(chain, n))
+}
+
 // Delete returns a new tree that does not contain the key `k`, and
 // a boolean indicating whether or not an element was removed.
 func (r *RBTree[T, U]) Delete(k T) (*RBTree[T, U], bool) {
@@ -413,11 +500,12 @@ func duplicate[T cmp.Ordered, U any](t *RBTree[T, U]) *RBTree[T, U] {
 		return nil
 	}
 	return &RBTree[T, U]{
-		k: t.k,
-		v: t.v,
-		c: t.c,
-		l: t.l,
-		r: t.r,
+		k:     t.k,
+		v:     t.v,
+		c:     t.c,
+		count: t.count,
+		l:     t.l,
+		r:     t.r,
 	}
 }
 
@@ -615,14 +703,15 @@ d6:
 	return np
 }
 
-func replace[T cmp.Ordered, U any](t *RBTree[T, U], i, j T) {
+func replace[T cmp.Ordered, U any](t *RBTree[T, U], i, j T, v U) {
 	switch {
 	case t.k == i:
 		t.k = j
+		t.v = v
 	case t.k < i:
-		replace(t.r, i, j)
+		replace(t.r, i, j, v)
 	case t.k > i:
-		replace(t.l, i, j)
+		replace(t.l, i, j, v)
 	}
 	return
 }
@@ -634,91 +723,7 @@ func (r *RBTree[T, U]) tree_delete(k T, chain []*RBTree[T, U]) (*RBTree[T, U], b
 	chain = append(chain, r)
 	switch {
 	case r.k == k:
-		if r.l != nil && r.r != nil {
-			// can swap our value with our in-order predecessor
-			predec, newTree := r.l.removeLargest(chain)
-			// It is safe to modify newTree because the node containing r.i
-			// will be new within newTree.
-			// This can be optimized in the future so we don't have to
-			// re-traverse the tree to find i.
-			replace(newTree, r.k, predec.k)
-			return newTree, true
-		} else if r.l != nil {
-			// Only left child
-			// replace this node with it's child and color it black.
-			chain = rechain(chain)
-			r = chain[len(chain)-1]
-			r.l = &RBTree[T, U]{
-				k: r.l.k,
-				v: r.l.v,
-				c: r.l.c,
-				l: r.l.l,
-				r: r.l.r,
-			}
-			r.l.c = black
-			if len(chain) > 1 {
-				p := chain[len(chain)-2]
-				if p.l == r {
-					p.l = r.l
-				} else if p.r == r {
-					p.r = r.l
-				} else {
-					panic("foo")
-				}
-				return chain[0], true
-			}
-			return r.l, true
-		} else if r.r != nil {
-			// Only left child
-			// replace this node with its child and color it black.
-			chain = rechain(chain)
-			r = chain[len(chain)-1]
-			r.l = &RBTree[T, U]{
-				k: r.r.k,
-				v: r.r.v,
-				c: r.r.c,
-				l: r.r.l,
-				r: r.r.r,
-			}
-			r.r.c = black
-			if len(chain) > 1 {
-				p := chain[len(chain)-2]
-				if p.l == r {
-					p.l = r.r
-				} else if p.r == r {
-					p.r = r.r
-				} else {
-					panic("foo")
-				}
-				return chain[0], true
-			}
-			return r.r, true
-		} else {
-			// No children
-			if len(chain) == 1 {
-				// No children and we are the root.
-				return nil, true
-			}
-			chain = rechain(chain)
-			r = chain[len(chain)-1]
-			p := chain[len(chain)-2]
-			if r.c == red {
-				if p.l == r {
-					p.l = nil
-				} else if p.r == r {
-					p.r = nil
-				} else {
-					panic("foo2")
-				}
-				// No children and we are a red node.
-				return chain[0], true
-			}
-			// No children and we are a black node. This will create an imbalance
-			// and we need to fix the tree.
-			//return rebalance_del(chain), true
-			rb := rebalance_del(chain)
-			return rb, true
-		}
+		return deleteAt(chain), true
 	case r.k > k:
 		if nl, ok := r.l.tree_delete(k, chain); ok {
 			return nl, true
@@ -733,6 +738,102 @@ func (r *RBTree[T, U]) tree_delete(k T, chain []*RBTree[T, U]) (*RBTree[T, U], b
 	panic("not possible.")
 }
 
+// deleteAt removes the last node in chain from the tree and returns the
+// new root. chain is the path from the root to the node.
+func deleteAt[T cmp.Ordered, U any](chain []*RBTree[T, U]) *RBTree[T, U] {
+	r := chain[len(chain)-1]
+	if r.l != nil && r.r != nil {
+		// can swap our value with our in-order predecessor
+		predec, newTree := r.l.removeLargest(chain)
+		// It is safe to modify newTree because the node containing r.i
+		// will be new within newTree.
+		// This can be optimized in the future so we don't have to
+		// re-traverse the tree to find i.
+		replace(newTree, r.k, predec.k, predec.v)
+		return newTree
+	} else if r.l != nil {
+		// Only left child
+		// replace this node with it's child and color it black.
+		chain = rechain(chain)
+		shrink(chain)
+		r = chain[len(chain)-1]
+		r.l = &RBTree[T, U]{
+			k:     r.l.k,
+			v:     r.l.v,
+			c:     r.l.c,
+			count: r.l.count,
+			l:     r.l.l,
+			r:     r.l.r,
+		}
+		r.l.c = black
+		if len(chain) > 1 {
+			p := chain[len(chain)-2]
+			if p.l == r {
+				p.l = r.l
+			} else if p.r == r {
+				p.r = r.l
+			} else {
+				panic("foo")
+			}
+			return chain[0]
+		}
+		return r.l
+	} else if r.r != nil {
+		// Only right child
+		// replace this node with its child and color it black.
+		chain = rechain(chain)
+		shrink(chain)
+		r = chain[len(chain)-1]
+		r.r = &RBTree[T, U]{
+			k:     r.r.k,
+			v:     r.r.v,
+			c:     r.r.c,
+			count: r.r.count,
+			l:     r.r.l,
+			r:     r.r.r,
+		}
+		r.r.c = black
+		if len(chain) > 1 {
+			p := chain[len(chain)-2]
+			if p.l == r {
+				p.l = r.r
+			} else if p.r == r {
+				p.r = r.r
+			} else {
+				panic("foo")
+			}
+			return chain[0]
+		}
+		return r.r
+	} else {
+		// No children
+		if len(chain) == 1 {
+			// No children and we are the root.
+			return nil
+		}
+		chain = rechain(chain)
+		shrink(chain)
+		r = chain[len(chain)-1]
+		p := chain[len(chain)-2]
+		if r.c == red {
+			if p.l == r {
+				p.l = nil
+			} else if p.r == r {
+				p.r = nil
+			} else {
+				panic("foo2")
+			}
+			// No children and we are a red node.
+			return chain[0]
+		}
+		// No children and we are a black node. This will create an imbalance
+		// and we need to fix the tree.
+		//return rebalance_del(chain), true
+		rb := rebalance_del(chain)
+		return rb
+	}
+}
+
 func (t *RBTree[T, U]) removeLargest(chain []*RBTree[T, U]) (largest, tree *RBTree[T, U]) {
 	if t.r != nil {
 		return t.r.removeLargest(append(chain, t))
@@ -0,0 +1,265 @@
+package ion
+
+import (
+	"iter"
+)
+
+// RBTreeFunc is a tree-based map of keys of type T to values of type U,
+// ordered by a user-supplied comparison function. It supports keys which
+// are not cmp.Ordered, such as structs, or strings compared without regard
+// to case. For cmp.Ordered keys, RBTree is faster.
+//
+// Like RBTree, RBTreeFunc is immutable. The comparison function is
+// stored once, alongside the root of the tree, and is shared by every
+// tree derived from it.
+//
+// The zero value is not usable. Use NewRBTreeFunc to create an empty tree.
+type RBTreeFunc[T, U any] struct {
+	cmp  func(a, b T) int
+	root rbh[*rbFuncNode[T, U]]
+}
+
+// NewRBTreeFunc returns an empty RBTreeFunc, ordered by `cmp`. `cmp`
+// must return a negative number if a < b, a positive number if a > b,
+// and 0 if a and b are equal, like cmp.Compare.
+func NewRBTreeFunc[T, U any](cmp func(a, b T) int) *RBTreeFunc[T, U] {
+	return &RBTreeFunc[T, U]{cmp: cmp}
+}
+
+type rbFuncNode[T, U any] struct {
+	c     color
+	count uint64
+	k     T
+	v     U
+	l     *rbFuncNode[T, U]
+	r     *rbFuncNode[T, U]
+}
+
+func (n *rbFuncNode[T, U]) empty() bool           { return n == nil }
+func (n *rbFuncNode[T, U]) key() T                { return n.k }
+func (n *rbFuncNode[T, U]) value() U              { return n.v }
+func (n *rbFuncNode[T, U]) left() treeNode[T, U]  { return n.l }
+func (n *rbFuncNode[T, U]) right() treeNode[T, U] { return n.r }
+
+func (n *rbFuncNode[T, U]) size() uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.count
+}
+
+func (n *rbFuncNode[T, U]) nodeColor() color {
+	if n == nil {
+		return black
+	}
+	return n.c
+}
+
+func (n *rbFuncNode[T, U]) expose() (*rbFuncNode[T, U], T, U, *rbFuncNode[T, U]) {
+	return n.l, n.k, n.v, n.r
+}
+
+func (*rbFuncNode[T, U]) node(c color, l *rbFuncNode[T, U], k T, v U, r *rbFuncNode[T, U]) *rbFuncNode[T, U] {
+	return &rbFuncNode[T, U]{
+		c:     c,
+		count: l.size() + r.size() + 1,
+		k:     k,
+		v:     v,
+		l:     l,
+		r:     r,
+	}
+}
+
+func (r *RBTreeFunc[T, U]) joiner() joiner[T, U, rbh[*rbFuncNode[T, U]]] {
+	return joiner[T, U, rbh[*rbFuncNode[T, U]]]{
+		compare: r.cmp,
+		expose:  rbExpose[T, U, *rbFuncNode[T, U]],
+		join:    rbJoin[T, U, *rbFuncNode[T, U]],
+	}
+}
+
+func (r *RBTreeFunc[T, U]) with(root rbh[*rbFuncNode[T, U]]) *RBTreeFunc[T, U] {
+	if root == r.root {
+		return r
+	}
+	return &RBTreeFunc[T, U]{cmp: r.cmp, root: root}
+}
+
+// Insert returns a new tree, consisting of the original tree with the
+// key/value pair `k`/`v` added to it.
+func (r *RBTreeFunc[T, U]) Insert(k T, v U) *RBTreeFunc[T, U] {
+	return r.with(r.joiner().insert(r.root, k, v))
+}
+
+// Delete returns a new tree that does not contain the key `k`, and
+// a boolean indicating whether or not an element was removed.
+func (r *RBTreeFunc[T, U]) Delete(k T) (*RBTreeFunc[T, U], bool) {
+	root, ok := r.joiner().delete(r.root, k)
+	return r.with(root), ok
+}
+
+// Get looks up the element in the map associated with `k`.
+// It also returns a boolean indicating whether the value was found.
+func (r *RBTreeFunc[T, U]) Get(k T) (U, bool) {
+	n := r.root.t
+	for n != nil {
+		switch c := r.cmp(k, n.k); {
+		case c < 0:
+			n = n.l
+		case c > 0:
+			n = n.r
+		default:
+			return n.v, true
+		}
+	}
+	var v U
+	return v, false
+}
+
+// Size returns the number of elements present in the tree.
+// Size runs in constant time.
+func (r *RBTreeFunc[T, U]) Size() uint64 {
+	return r.root.t.size()
+}
+
+// Iterate executes `f` over the key/value pairs in the tree in ascending
+// key order, until `f` returns false.
+func (r *RBTreeFunc[T, U]) Iterate(f func(T, U) bool) {
+	iterateFrom[T, U](r.root.t, 0, f)
+}
+
+// Reverse executes `f` over the key/value pairs in the tree in descending
+// key order, until `f` returns false.
+func (r *RBTreeFunc[T, U]) Reverse(f func(T, U) bool) {
+	reverseNodes[T, U](r.root.t, f)
+}
+
+// All returns an iterator over the key/value pairs in the tree in
+// ascending key order.
+func (r *RBTreeFunc[T, U]) All() iter.Seq2[T, U] {
+	return r.Iterate
+}
+
+// Backward returns an iterator over the key/value pairs in the tree in
+// descending key order.
+func (r *RBTreeFunc[T, U]) Backward() iter.Seq2[T, U] {
+	return r.Reverse
+}
+
+// Keys returns a Seq containing the keys of the tree in ascending order.
+func (r *RBTreeFunc[T, U]) Keys() Seq[T] {
+	return newTreeSeq[T, U](r.root.t, keyOf[T, U])
+}
+
+// Values returns a Seq containing the values of the tree, in ascending
+// order of their keys.
+func (r *RBTreeFunc[T, U]) Values() Seq[U] {
+	return newTreeSeq[T, U](r.root.t, valueOf[T, U])
+}
+
+// Entries returns a Seq containing the key/value pairs of the tree in
+// ascending key order. Elem on the returned Seq runs in O(log n) time.
+func (r *RBTreeFunc[T, U]) Entries() Seq[Pair[T, U]] {
+	return newTreeSeq[T, U](r.root.t, MakePair[T, U])
+}
+
+// Min returns the smallest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (r *RBTreeFunc[T, U]) Min() (T, U, bool) {
+	return minNode[T, U](r.root.t)
+}
+
+// Max returns the largest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (r *RBTreeFunc[T, U]) Max() (T, U, bool) {
+	return maxNode[T, U](r.root.t)
+}
+
+// Floor returns the largest key in the tree less than or equal to `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTreeFunc[T, U]) Floor(k T) (T, U, bool) {
+	return floor[T, U](r.root.t, r.cmp, k, true)
+}
+
+// Ceiling returns the smallest key in the tree greater than or equal to
+// `k`, and its value. If there is no such key, it returns false.
+func (r *RBTreeFunc[T, U]) Ceiling(k T) (T, U, bool) {
+	return ceiling[T, U](r.root.t, r.cmp, k, true)
+}
+
+// Lower returns the largest key in the tree strictly less than `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTreeFunc[T, U]) Lower(k T) (T, U, bool) {
+	return floor[T, U](r.root.t, r.cmp, k, false)
+}
+
+// Higher returns the smallest key in the tree strictly greater than `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTreeFunc[T, U]) Higher(k T) (T, U, bool) {
+	return ceiling[T, U](r.root.t, r.cmp, k, false)
+}
+
+// Range returns a Seq of the key/value pairs in the tree with keys
+// between `lo` and `hi`, in ascending key order. `b` determines whether
+// `lo` and `hi` themselves are included in the range.
+func (r *RBTreeFunc[T, U]) Range(lo, hi T, b Bounds) Seq[Pair[T, U]] {
+	return treeRange[T, U](r.root.t, r.cmp, lo, hi, b)
+}
+
+// Rank returns the number of keys in the tree less than `k`.
+// Rank runs in O(log n) time.
+func (r *RBTreeFunc[T, U]) Rank(k T) uint64 {
+	return rank[T, U](r.root.t, r.cmp, k, false)
+}
+
+// Select returns the `i`th smallest key in the tree, starting from 0,
+// and its value. If the tree has `i` or fewer keys, it returns false.
+// Select runs in O(log n) time.
+func (r *RBTreeFunc[T, U]) Select(i uint64) (T, U, bool) {
+	return nth[T, U](r.root.t, i)
+}
+
+// Union returns a tree containing every key present in either `r` or `o`.
+// Keys present in both trees are given the value returned by `resolve`,
+// which is passed the key and the values from `r` and `o`, in that order.
+// If `resolve` is nil, the values from `r` are kept.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `r`.
+func (r *RBTreeFunc[T, U]) Union(o *RBTreeFunc[T, U], resolve func(k T, a, b U) U) *RBTreeFunc[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return r.with(r.joiner().union(r.root, o.root, resolve))
+}
+
+// Intersection returns a tree containing the keys present in both `r` and
+// `o`, with values given by `resolve`, which is passed the key and the
+// values from `r` and `o`, in that order. If `resolve` is nil, the values
+// from `r` are kept.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `r`.
+func (r *RBTreeFunc[T, U]) Intersection(o *RBTreeFunc[T, U], resolve func(k T, a, b U) U) *RBTreeFunc[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return r.with(r.joiner().intersection(r.root, o.root, resolve))
+}
+
+// Difference returns a tree containing the entries of `r` whose keys are
+// not present in `o`.
+//
+// The trees must be ordered by equivalent comparison functions.
+func (r *RBTreeFunc[T, U]) Difference(o *RBTreeFunc[T, U]) *RBTreeFunc[T, U] {
+	return r.with(r.joiner().difference(r.root, o.root))
+}
+
+// SymmetricDifference returns a tree containing the entries of `r` and `o`
+// whose keys are present in only one of the two trees.
+//
+// The trees must be ordered by equivalent comparison functions. The
+// result uses the comparison function of `r`.
+func (r *RBTreeFunc[T, U]) SymmetricDifference(o *RBTreeFunc[T, U]) *RBTreeFunc[T, U] {
+	return r.with(r.joiner().symmetricDifference(r.root, o.root))
+}
@@ -348,3 +348,38 @@ func validateRBTreer(t *RBTree[uint64, uint64], count, depth int) *RBTree[uint64
 	}
 	return nil
 }
+
+// Regression test. Deleting a node with two children moves its in-order
+// predecessor into its place, and used to move only the key, leaving the
+// deleted node's value behind.
+func TestRBTreeDeleteValues(t *testing.T) {
+	var r *RBTree[uint64, uint64]
+	ref := make(map[uint64]uint64)
+	for i := uint64(0); i < 2000; i++ {
+		k := uint64(rand.Intn(1000))
+		r = r.Insert(k, k*10+1)
+		ref[k] = k*10 + 1
+	}
+	orig := r
+	for i := 0; i < 600; i++ {
+		k := uint64(rand.Intn(1000))
+		var ok bool
+		r, ok = r.Delete(k)
+		if _, present := ref[k]; ok != present {
+			t.Fatalf("Delete(%d): Expected %t, but got %t", k, present, ok)
+		}
+		delete(ref, k)
+		for k, v := range ref {
+			if rv, ok := r.Get(k); !ok || rv != v {
+				t.Fatalf("After deleting: Expected %d => %d, but got %d (%t)", k, v, rv, ok)
+			}
+		}
+	}
+	// The original tree must be unaffected.
+	orig.Iterate(func(k, v uint64) bool {
+		if v != k*10+1 {
+			t.Fatalf("Original tree: Expected %d => %d, but got %d", k, k*10+1, v)
+		}
+		return true
+	})
+}
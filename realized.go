@@ -0,0 +1,185 @@
+package ion
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// tailSize is the number of elements a prefix collects in its tail before
+// they are moved into its Vec.
+const tailSize = 8 * spanSize
+
+// prefix is a snapshot of the realized prefix of a sequence. Its elements
+// are those of v, followed by those of tail. A nil *prefix is empty.
+//
+// Elements are appended to tail in place, past the length seen by older
+// snapshots, which is much cheaper than appending them to v one at a time.
+// Once tail is full, it is moved into v and a new tail is started.
+type prefix[T any] struct {
+	v    *Vec[T]
+	tail []T
+	// done is true if the sequence has ended, so the prefix holds all of
+	// its elements.
+	done bool
+}
+
+func (p *prefix[T]) len() uint64 {
+	if p == nil {
+		return 0
+	}
+	return p.v.Len() + uint64(len(p.tail))
+}
+
+func (p *prefix[T]) ended() bool {
+	return p != nil && p.done
+}
+
+func (p *prefix[T]) elem(i uint64) (T, bool) {
+	if p == nil {
+		var ret T
+		return ret, false
+	}
+	if l := p.v.Len(); i >= l {
+		if i -= l; i < uint64(len(p.tail)) {
+			return p.tail[i], true
+		}
+		var ret T
+		return ret, false
+	}
+	return p.v.Elem(i)
+}
+
+// take returns the first n elements of the prefix as a Vec.
+func (p *prefix[T]) take(n uint64) *Vec[T] {
+	if p == nil {
+		return nil
+	}
+	l := p.v.Len()
+	if n < l {
+		v, _ := p.v.split(n)
+		return v
+	}
+	tail := p.tail[:min(n-l, uint64(len(p.tail)))]
+	if len(tail) == 0 {
+		return p.v
+	}
+	return p.v.Join(BuildVec(func(add func(T)) {
+		for _, e := range tail {
+			add(e)
+		}
+	}))
+}
+
+// iterate executes `f` over the elements of the prefix starting at index
+// i. It returns false if `f` returned false.
+func (p *prefix[T]) iterate(i uint64, f func(T) bool) bool {
+	if p == nil {
+		return true
+	}
+	l := p.v.Len()
+	if i < l {
+		v := p.v
+		if i > 0 {
+			_, v = p.v.split(i)
+		}
+		if !v.iterate(f) {
+			return false
+		}
+		i = l
+	}
+	for _, e := range p.tail[min(i-l, uint64(len(p.tail))):] {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// grow returns a new prefix holding the elements of p followed by those
+// `fill` passes to add, and the number of elements added. The new prefix
+// may share the backing array of p's tail, so grow must only be called on
+// the latest prefix, with the lock of its realized held.
+func (p *prefix[T]) grow(fill func(add func(T))) (*prefix[T], uint64) {
+	var np prefix[T]
+	if p != nil {
+		np = *p
+	}
+	var added uint64
+	fill(func(e T) {
+		np.tail = append(np.tail, e)
+		if len(np.tail) == tailSize {
+			tail := np.tail
+			np.v = np.v.Join(BuildVec(func(add func(T)) {
+				for _, e := range tail {
+					add(e)
+				}
+			}))
+			np.tail = nil
+		}
+		added++
+	})
+	return &np, added
+}
+
+// realized holds the realized prefix of a sequence whose elements must be
+// produced in order, such as the elements of a StateGen or a Memo.
+//
+// The prefix is published as an immutable *prefix, so any number of
+// goroutines can read it without locking. Only extending the prefix is
+// serialized. The zero value is an empty prefix.
+type realized[T any] struct {
+	p atomic.Pointer[prefix[T]]
+	m sync.Mutex
+}
+
+// extend realizes elements until at least n are realized, and returns the
+// realized prefix.
+//
+// If elements are missing, extend calls `fill` with the lock held and the
+// number of elements needed. `fill` must pass the next elements of the
+// sequence to add, and return true if the sequence has ended. It may pass
+// fewer elements than were needed without the sequence ending, in which
+// case the prefix is shorter than n but has not ended.
+func (r *realized[T]) extend(n uint64, fill func(need uint64, add func(T)) bool) *prefix[T] {
+	if p := r.p.Load(); p.len() >= n || p.ended() {
+		return p
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	p := r.p.Load()
+	if p.len() >= n || p.ended() {
+		return p
+	}
+	need := n - p.len()
+	var ended bool
+	p, _ = p.grow(func(add func(T)) {
+		ended = fill(need, add)
+	})
+	p.done = ended
+	r.p.Store(p)
+	return p
+}
+
+// iterateRealized executes `f` over the elements of a sequence from index
+// i up to, but not including, index `end`, until `f` returns false or the
+// sequence ends. `realize` must make sure at least n elements are realized
+// and return the realized prefix, which is shorter than n only if the
+// sequence has ended. Elements which are already realized are read without
+// locking.
+func iterateRealized[T any](i, end uint64, realize func(n uint64) *prefix[T], f func(T) bool) {
+	for i < end {
+		p := realize(i + 1)
+		if p.len() <= i {
+			return
+		}
+		if !p.iterate(i, func(e T) bool {
+			if i == end || !f(e) {
+				return false
+			}
+			i++
+			return true
+		}) {
+			return
+		}
+	}
+}
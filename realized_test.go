@@ -0,0 +1,191 @@
+package ion
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func counter() func() (int, bool) {
+	// Deliberately unsynchronized. The race detector will complain if
+	// the generator is ever run concurrently.
+	i := 0
+	return func() (int, bool) {
+		ret := i
+		i++
+		return ret, i <= 100000
+	}
+}
+
+func TestStateGenConcurrent(t *testing.T) {
+	seq := StateGen(counter())
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			switch g % 4 {
+			case 0:
+				expect := 0
+				seq.Iterate(func(e int) bool {
+					if e != expect {
+						t.Errorf("Expected %d, but got %d", expect, e)
+						return false
+					}
+					expect++
+					return true
+				})
+				if expect != 100000 {
+					t.Errorf("Expected 100000 elements, but got %d", expect)
+				}
+			case 1:
+				for i := uint64(g * 1000); i < 100000; i += 777 {
+					if e, ok := seq.Elem(i); !ok || e != int(i) {
+						t.Errorf("Expected seq[%d] == %d, but got %d", i, i, e)
+						return
+					}
+				}
+			case 2:
+				_, r := seq.Split(uint64(g * 1000))
+				var n int
+				r.Lazy(func(e func() int) bool {
+					if v := e(); v != g*1000+n {
+						t.Errorf("Expected %d, but got %d", g*1000+n, v)
+						return false
+					}
+					n++
+					return true
+				})
+			case 3:
+				l := seq.Take(uint64(g * 5000))
+				if n := len(ToSlice(l)); n != g*5000 {
+					t.Errorf("Expected %d elements, but got %d", g*5000, n)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if _, ok := seq.Elem(100000); ok {
+		t.Fatalf("Expected no element at index 100000")
+	}
+}
+
+func TestStateGenSlowReader(t *testing.T) {
+	seq := StateGen(counter())
+	seq.Elem(1000)
+
+	// A reader blocked in the middle of the realized prefix must not
+	// block other readers, even past the end of the prefix.
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	go seq.Iterate(func(e int) bool {
+		if e == 500 {
+			close(blocked)
+			<-release
+		}
+		return e < 2000
+	})
+	<-blocked
+	defer close(release)
+
+	done := make(chan int)
+	go func() {
+		var sum int
+		seq.Take(5000).Iterate(func(e int) bool {
+			sum += e
+			return true
+		})
+		done <- sum
+	}()
+	select {
+	case sum := <-done:
+		if sum != 4999*5000/2 {
+			t.Fatalf("Expected sum of %d, but got %d", 4999*5000/2, sum)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out. A slow reader blocked another reader.")
+	}
+}
+
+func TestMemoConcurrent(t *testing.T) {
+	var calls atomic.Int64
+	seq := Memo(Map(From[int](0, 1), func(i int) int {
+		calls.Add(1)
+		return i * 2
+	}))
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			if g%2 == 0 {
+				for i := uint64(20000 - g*100); i > 0; i -= min(i, 313) {
+					if e, ok := seq.Elem(i); !ok || e != int(i*2) {
+						t.Errorf("Expected seq[%d] == %d, but got %d", i, i*2, e)
+						return
+					}
+				}
+				return
+			}
+			_, r := seq.Split(uint64(g * 100))
+			var n int
+			r.Take(10000).Iterate(func(e int) bool {
+				if e != (g*100+n)*2 {
+					t.Errorf("Expected %d, but got %d", (g*100+n)*2, e)
+					return false
+				}
+				n++
+				return true
+			})
+			if n != 10000 {
+				t.Errorf("Expected 10000 elements, but got %d", n)
+			}
+		}(g)
+	}
+	wg.Wait()
+	// Every element is computed only once.
+	if n := calls.Load(); n != 20001 {
+		t.Fatalf("Expected 20001 calls, but got %d", n)
+	}
+}
+
+func TestStateGenConcurrentEnd(t *testing.T) {
+	// Readers racing to the end of a short sequence must never miss an
+	// element which exists, even while another reader finds the end.
+	for trial := 0; trial < 200; trial++ {
+		i := 0
+		seq := StateGen(func() (int, bool) {
+			i++
+			return i - 1, i <= 100
+		})
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				if g%2 == 0 {
+					for j := uint64(90 + g); j < 100; j++ {
+						if e, ok := seq.Elem(j); !ok || e != int(j) {
+							t.Errorf("Expected seq[%d] == %d, but got %d, %t", j, j, e, ok)
+							return
+						}
+					}
+					if _, ok := seq.Elem(100 + uint64(g)); ok {
+						t.Errorf("Expected no element at index %d", 100+g)
+					}
+					return
+				}
+				var n int
+				seq.Iterate(func(e int) bool {
+					n++
+					return true
+				})
+				if n != 100 {
+					t.Errorf("Expected 100 elements, but got %d", n)
+				}
+			}(g)
+		}
+		wg.Wait()
+	}
+}
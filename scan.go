@@ -0,0 +1,115 @@
+package ion
+
+type scanSeq[T, U any] struct {
+	s   Seq[T]
+	acc U
+	f   func(U, T) U
+}
+
+func (s *scanSeq[T, U]) Elem(i uint64) (U, bool) {
+	acc := s.acc
+	var j uint64
+	var found bool
+	s.s.Iterate(func(e T) bool {
+		acc = s.f(acc, e)
+		if j == i {
+			found = true
+			return false
+		}
+		j++
+		return true
+	})
+	if !found {
+		var r U
+		return r, false
+	}
+	return acc, true
+}
+
+func (s *scanSeq[T, U]) Split(n uint64) (Seq[U], Seq[U]) {
+	if n == 0 {
+		return (*Vec[U])(nil), s
+	}
+
+	// We need the accumulator at n for the remaining seq
+	acc := s.acc
+	var i uint64
+	s.s.Iterate(func(e T) bool {
+		acc = s.f(acc, e)
+		i++
+		return i < n
+	})
+	if i < n {
+		// We didn't reach n
+		return s, (*Vec[U])(nil)
+	}
+
+	_, sr := s.s.Split(n)
+	l := &scanSeq[T, U]{
+		s:   s.s.Take(n),
+		acc: s.acc,
+		f:   s.f,
+	}
+	r := &scanSeq[T, U]{
+		s:   sr,
+		acc: acc,
+		f:   s.f,
+	}
+	return l, r
+}
+
+func (s *scanSeq[T, U]) Take(n uint64) Seq[U] {
+	if n == 0 {
+		return (*Vec[U])(nil)
+	}
+	return &scanSeq[T, U]{
+		s:   s.s.Take(n),
+		acc: s.acc,
+		f:   s.f,
+	}
+}
+
+func (s *scanSeq[T, U]) Iterate(f func(U) bool) {
+	acc := s.acc
+	s.s.Iterate(func(e T) bool {
+		acc = s.f(acc, e)
+		return f(acc)
+	})
+}
+
+func (s *scanSeq[T, U]) Lazy(f func(func() U) bool) {
+	// Like Generate, this cannot be lazy, because each element
+	// depends on the one before it. We evaluate the current element
+	// and return a closure that returns it.
+	acc := s.acc
+	s.s.Iterate(func(e T) bool {
+		acc = s.f(acc, e)
+		a := acc
+		return f(func() U { return a })
+	})
+}
+
+// Scan is like Fold, but rather than returning only the final accumulator,
+// it returns a Seq[U] containing each successive accumulator value.
+// Element i of the result is the accumulator after `f` has been run over
+// elements 0 through i of `s`, starting with `init`. `init` itself is not
+// included in the result.
+//
+// Unlike Fold, Scan is lazy, so it is safe and useful to Scan unbounded
+// sequences. For example, the running totals of the natural numbers:
+//
+//	// 1, 3, 6, 10, 15, ...
+//	totals := Scan(From[int](1, 1), 0, func(acc, i int) int {
+//		return acc + i
+//	})
+//
+// Since each element depends on the one before it, Elem(i) must run `f`
+// over the first i+1 elements of `s`. As with Map, `f` may be called multiple
+// times on the same element, so it should be idempotent and stateless.
+func Scan[T, U any](s Seq[T], init U, f func(U, T) U) Seq[U] {
+	return &scanSeq[T, U]{
+		s:   s,
+		acc: init,
+		f:   f,
+	}
+}
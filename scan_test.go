@@ -0,0 +1,47 @@
+package ion
+
+import (
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	count := func(acc, i int) int { return acc + i }
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Scan(Repeatedly(1), -1, count))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, Scan(Repeatedly(1).Take(10000), -1, count))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		testFinSeq(t, Scan(Repeatedly(1), -1, count).Take(10000))
+	})
+	t.Run("fin3", func(t *testing.T) {
+		var i int
+		l, _ := Scan(StateGen(func() (int, bool) {
+			i++
+			return 1, true
+		}), -1, count).Split(10000)
+		testFinSeq(t, l)
+	})
+	t.Run("totals", func(t *testing.T) {
+		totals := Scan(From[int](1, 1), 0, func(acc, i int) int {
+			return acc + i
+		})
+		_, r := totals.Split(3)
+		// 10, 15, 21
+		if s := ToSlice(r.Take(3)); len(s) != 3 || s[0] != 10 || s[1] != 15 || s[2] != 21 {
+			t.Fatalf("Expected [10 15 21], but got %v", s)
+		}
+	})
+	t.Run("type", func(t *testing.T) {
+		s := Scan(From[int](0, 1).Take(5), "", func(acc string, i int) string {
+			return acc + string(rune('a'+i))
+		})
+		if e, ok := s.Elem(4); !ok || e != "abcde" {
+			t.Fatalf("Expected s[4] == abcde, but was %q", e)
+		}
+		if e, ok := s.Elem(5); ok {
+			t.Fatalf("Expected s[5] to return no value, but got %q", e)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package ion
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Set is an ordered set of elements of type T.
+//
+// Set is immutable, meaning operations performed on it return new sets
+// without modifying the old. It is backed by an RBTree whose values are
+// zero-sized, so each element costs no more than a tree node.
+//
+// The zero value of Set is the empty set.
+type Set[T cmp.Ordered] struct {
+	t *RBTree[T, struct{}]
+}
+
+// SetOf returns a Set containing `elems`.
+func SetOf[T cmp.Ordered](elems ...T) Set[T] {
+	var s Set[T]
+	for _, e := range elems {
+		s = s.Add(e)
+	}
+	return s
+}
+
+// SetFromSeq returns a Set containing the elements of `s`.
+// `s` must be finite.
+func SetFromSeq[T cmp.Ordered](s Seq[T]) Set[T] {
+	return Set[T]{RBTreeFromSeq(Map(s, func(e T) Pair[T, struct{}] {
+		return Pair[T, struct{}]{e, struct{}{}}
+	}))}
+}
+
+// Add returns a set containing the elements of `s` and `e`.
+func (s Set[T]) Add(e T) Set[T] {
+	if s.Contains(e) {
+		return s
+	}
+	return Set[T]{s.t.Insert(e, struct{}{})}
+}
+
+// Remove returns a set containing the elements of `s` except `e`.
+func (s Set[T]) Remove(e T) Set[T] {
+	if t, ok := s.t.Delete(e); ok {
+		return Set[T]{t}
+	}
+	return s
+}
+
+// Contains returns true if `e` is an element of `s`.
+func (s Set[T]) Contains(e T) bool {
+	_, ok := s.t.Get(e)
+	return ok
+}
+
+// Len returns the number of elements in `s`. Len runs in constant time.
+func (s Set[T]) Len() uint64 {
+	return s.t.Size()
+}
+
+// Iterate executes `f` over the elements of `s` in ascending order,
+// until `f` returns false.
+func (s Set[T]) Iterate(f func(T) bool) {
+	s.t.iterate(func(e T, _ struct{}) bool {
+		return f(e)
+	})
+}
+
+// All returns an iterator over the elements of `s` in ascending order.
+func (s Set[T]) All() iter.Seq[T] {
+	return s.Iterate
+}
+
+// Elements returns a Seq containing the elements of `s` in ascending order.
+func (s Set[T]) Elements() Seq[T] {
+	return s.t.Keys()
+}
+
+// Min returns the smallest element of `s`. If `s` is empty, it returns
+// false.
+func (s Set[T]) Min() (T, bool) {
+	e, _, ok := s.t.Min()
+	return e, ok
+}
+
+// Max returns the largest element of `s`. If `s` is empty, it returns
+// false.
+func (s Set[T]) Max() (T, bool) {
+	e, _, ok := s.t.Max()
+	return e, ok
+}
+
+// Union returns a set containing the elements present in either `s` or `o`.
+func (s Set[T]) Union(o Set[T]) Set[T] {
+	return Set[T]{s.t.Union(o.t, nil)}
+}
+
+// Intersection returns a set containing the elements present in both `s`
+// and `o`.
+func (s Set[T]) Intersection(o Set[T]) Set[T] {
+	return Set[T]{s.t.Intersection(o.t, nil)}
+}
+
+// Difference returns a set containing the elements of `s` which are not
+// present in `o`.
+func (s Set[T]) Difference(o Set[T]) Set[T] {
+	return Set[T]{s.t.Difference(o.t)}
+}
+
+// SymmetricDifference returns a set containing the elements present in
+// exactly one of `s` and `o`.
+func (s Set[T]) SymmetricDifference(o Set[T]) Set[T] {
+	return Set[T]{s.t.SymmetricDifference(o.t)}
+}
+
+// IsSubset returns true if every element of `s` is also an element of `o`.
+func (s Set[T]) IsSubset(o Set[T]) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	subset := true
+	s.Iterate(func(e T) bool {
+		subset = o.Contains(e)
+		return subset
+	})
+	return subset
+}
+
+// Equal returns true if `s` and `o` contain the same elements.
+func (s Set[T]) Equal(o Set[T]) bool {
+	return s.Len() == o.Len() && s.IsSubset(o)
+}
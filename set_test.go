@@ -0,0 +1,90 @@
+package ion
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+func TestSet(t *testing.T) {
+	var s Set[int]
+	for i := 0; i < 1000; i++ {
+		s = s.Add(i % 500)
+	}
+	if s.Len() != 500 {
+		t.Fatalf("Expected 500 elements, but got %d", s.Len())
+	}
+	for i := 0; i < 500; i += 2 {
+		s = s.Remove(i)
+	}
+	s = s.Remove(10000)
+	if s.Len() != 250 {
+		t.Fatalf("Expected 250 elements, but got %d", s.Len())
+	}
+	for i := 0; i < 500; i++ {
+		if s.Contains(i) != (i%2 == 1) {
+			t.Fatalf("Expected Contains(%d) == %t", i, i%2 == 1)
+		}
+	}
+	t.Run("elements", func(t *testing.T) {
+		testFinSeq(t, SetFromSeq(From[int](0, 1).Take(10000)).Elements())
+	})
+
+	prev := -1
+	for e := range s.All() {
+		if e <= prev {
+			t.Fatalf("Expected ascending order, but got %d after %d", e, prev)
+		}
+		prev = e
+	}
+	if e, ok := s.Min(); !ok || e != 1 {
+		t.Fatalf("Expected Min() == 1, but got %d", e)
+	}
+	if e, ok := s.Max(); !ok || e != 499 {
+		t.Fatalf("Expected Max() == 499, but got %d", e)
+	}
+
+	var empty Set[string]
+	if empty.Len() != 0 || empty.Contains("") {
+		t.Fatalf("Expected the zero Set to be empty")
+	}
+	if _, ok := empty.Min(); ok {
+		t.Fatalf("Expected no minimum in an empty Set")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := SetFromSeq(From[int](0, 2).Take(50)) // evens < 100
+	b := SetFromSeq(From[int](0, 3).Take(34)) // multiples of 3 < 100
+	c := SetOf(0, 6, 12)
+
+	str := func(s Set[int]) string { return fmt.Sprint(ToSlice(s.Elements())) }
+	if n := a.Union(b).Len(); n != 67 {
+		t.Fatalf("Expected union of 67, but got %d", n)
+	}
+	if s := a.Intersection(b); s.Len() != 17 || !s.Equal(SetFromSeq(From[int](0, 6).Take(17))) {
+		t.Fatalf("Expected multiples of 6, but got %s", str(s))
+	}
+	if n := a.Difference(b).Len(); n != 33 {
+		t.Fatalf("Expected difference of 33, but got %d", n)
+	}
+	if n := a.SymmetricDifference(b).Len(); n != 50 {
+		t.Fatalf("Expected symmetric difference of 50, but got %d", n)
+	}
+	if !c.IsSubset(a) || !c.IsSubset(b) || a.IsSubset(b) || a.IsSubset(c) {
+		t.Fatalf("Wrong subset relations")
+	}
+	if !(Set[int]{}).IsSubset(c) {
+		t.Fatalf("Expected the empty set to be a subset of every set")
+	}
+	if a.Equal(b) || !a.Equal(a.Union(c)) {
+		t.Fatalf("Wrong equality")
+	}
+}
+
+func TestSetNodeSize(t *testing.T) {
+	// The zero-sized values should not take up any space in the nodes.
+	if a, b := unsafe.Sizeof(RBTree[int, struct{}]{}), unsafe.Sizeof(RBTree[int, bool]{}); a >= b {
+		t.Fatalf("Expected set nodes (%d bytes) to be smaller than map nodes (%d bytes)", a, b)
+	}
+}
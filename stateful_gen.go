@@ -1,65 +1,37 @@
 package ion
 
-import (
-	"sync"
-)
+import "math"
 
 type stateGen[T any] struct {
-	f    func() (T, bool)
-	mems *Vec[T]
-	m    sync.Mutex
+	f func() (T, bool)
+	r realized[T]
 }
 
-func (g *stateGen[T]) Elem(i uint64) (T, bool) {
-	g.m.Lock()
-	defer g.m.Unlock()
-
-	memlen := g.mems.Len()
-	if i >= memlen {
-		var finished bool
-		need := i - memlen + 1
-		//fmt.Printf("Taking %d new elements.\n", need)
-		newmems := BuildVec(func(add func(e T)) {
-			for j := uint64(0); j < need; j++ {
-				next, cont := g.f()
-				if !cont {
-					finished = false
-					return
-				}
-				add(next)
-			}
-			finished = true
-		})
-		g.mems = g.mems.Join(newmems)
-		if !finished {
-			var r T
-			return r, false
+// fill generates up to `need` new elements with g.f, and returns true if
+// g.f ended the sequence.
+func (g *stateGen[T]) fill(need uint64, add func(T)) bool {
+	for j := uint64(0); j < need; j++ {
+		next, cont := g.f()
+		if !cont {
+			return true
 		}
+		add(next)
 	}
-	// fmt.Printf("g.mems len: %d\n", g.mems.Len())
-	// //fmt.Printf("g.mems: %v\n", ToSlice(g.mems))
-	// var ii, res int
-	// g.mems.Iterate(func(e T) bool {
-	// 	var ei any
-	// 	ei = e
-	// 	res += ei.(int)
-	// 	ii++
-	// 	if ii == 10000 {
-	// 		return false
-	// 	}
-	// 	return true
-	// })
-	// fmt.Printf("g.mems sum: %d\n", res)
-	return g.mems.Elem(i)
+	return false
 }
 
-func (g *stateGen[T]) Split(n uint64) (Seq[T], Seq[T]) {
-	g.Elem(n)
-	g.m.Lock()
-	defer g.m.Unlock()
+// realize makes sure at least n elements are generated, and returns the
+// realized prefix.
+func (g *stateGen[T]) realize(n uint64) *prefix[T] {
+	return g.r.extend(n, g.fill)
+}
+
+func (g *stateGen[T]) Elem(i uint64) (T, bool) {
+	return g.realize(i + 1).elem(i)
+}
 
-	//l, r := g.mems.Split(n)
-	l := g.mems.Take(n)
+func (g *stateGen[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	l := g.realize(n + 1).take(n)
 	spl := &splitStateGen[T]{
 		g:     g,
 		start: n,
@@ -68,67 +40,19 @@ func (g *stateGen[T]) Split(n uint64) (Seq[T], Seq[T]) {
 }
 
 func (g *stateGen[T]) Take(n uint64) Seq[T] {
-	g.Elem(n)
-	g.m.Lock()
-	defer g.m.Unlock()
-
-	return g.mems.Take(n)
+	return g.realize(n + 1).take(n)
 }
 
 func (g *stateGen[T]) Iterate(f func(T) bool) {
-	// TODO: lame only one thread can iterate an immutable struct at a time.
-	// need to figure this out.
-	g.m.Lock()
-	defer g.m.Unlock()
-	quit := false
-	g.mems.Iterate(func(e T) bool {
-		if !f(e) {
-			quit = true
-			return false
-		}
-		return true
-	})
-	if quit {
-		return
-	}
-	for {
-		e, cont := g.f()
-		if !cont {
-			return
-		}
-		g.mems = g.mems.Append(e)
-		if !f(e) {
-			return
-		}
-	}
+	iterateRealized(0, math.MaxUint64, g.realize, f)
 }
 
 func (g *stateGen[T]) Lazy(f func(func() T) bool) {
-	// TODO: lame only one thread can iterate an immutable seq at a time.
-	// need to figure this out.
-	g.m.Lock()
-	defer g.m.Unlock()
-	quit := false
-	g.mems.Lazy(func(ef func() T) bool {
-		if !f(ef) {
-			quit = true
-			return false
-		}
-		return true
+	// Elements must be generated in order, so we realize each element
+	// before handing out its thunk.
+	iterateRealized(0, math.MaxUint64, g.realize, func(e T) bool {
+		return f(func() T { return e })
 	})
-	if quit {
-		return
-	}
-	for {
-		e, cont := g.f()
-		if !cont {
-			return
-		}
-		g.mems = g.mems.Append(e)
-		if !f(func() T { return e }) {
-			return
-		}
-	}
 }
 
 type splitStateGen[T any] struct {
@@ -157,13 +81,15 @@ func (g *splitStateGen[T]) Take(n uint64) Seq[T] {
 }
 
 func (g *splitStateGen[T]) Iterate(f func(T) bool) {
-	_, og := g.Split(g.start)
-	og.Iterate(f)
+	iterateRealized(g.start, math.MaxUint64, g.g.realize, f)
 }
 
 func (g *splitStateGen[T]) Lazy(f func(func() T) bool) {
-	_, og := g.Split(g.start)
-	og.Lazy(f)
+	// Like stateGen, elements must be generated in order, so we
+	// realize each element before handing out its thunk.
+	iterateRealized(g.start, math.MaxUint64, g.g.realize, func(e T) bool {
+		return f(func() T { return e })
+	})
 }
 
 // StateGen takes a func `f` and executes it in order to generate
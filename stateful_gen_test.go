@@ -90,3 +90,39 @@ func TestStateGenTakeElem(t *testing.T) {
 		t.Fatalf("Expected ll[9] == 19, true, but was %d, %t", e, ok)
 	}
 }
+
+// Regression test. Iterate and Lazy on the right half of a split StateGen
+// used to apply the split offset again, recursing without end.
+func TestStateGenSplitIterate(t *testing.T) {
+	i := 0
+	seq := StateGen(func() (int, bool) {
+		ret := i
+		i++
+		return ret, i <= 100
+	})
+	_, r := seq.Split(10)
+	expect := 10
+	r.Iterate(func(e int) bool {
+		if e != expect {
+			t.Fatalf("Expected %d, but got %d", expect, e)
+		}
+		expect++
+		return true
+	})
+	if expect != 100 {
+		t.Fatalf("Expected to iterate to 100, but stopped at %d", expect)
+	}
+
+	_, rr := r.Split(10)
+	expect = 20
+	rr.Lazy(func(e func() int) bool {
+		if v := e(); v != expect {
+			t.Fatalf("Expected %d, but got %d", expect, v)
+		}
+		expect++
+		return expect < 30
+	})
+	if expect != 30 {
+		t.Fatalf("Expected to stop at 30, but stopped at %d", expect)
+	}
+}
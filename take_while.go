@@ -0,0 +1,348 @@
+package ion
+
+import (
+	"math"
+	"sync"
+)
+
+// whileState tracks the evaluation of a predicate over a prefix of a Seq.
+// It records how many of the leading elements of s are known to satisfy f,
+// so that f is never run more than once on any element.
+type whileState[T any] struct {
+	s       Seq[T]
+	f       func(T) bool
+	m       sync.Mutex
+	checked uint64 // elements [0, checked) of s satisfy f
+	end     bool   // element checked does not satisfy f, or s ends at checked
+}
+
+// scan runs f over the elements of s until at least n elements are
+// known to satisfy f, or the end of the prefix is found. It returns the
+// number of elements known to satisfy f, and whether that is the
+// entire prefix.
+func (w *whileState[T]) scan(n uint64) (uint64, bool) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.mutScan(n)
+}
+
+// mutScan is scan, but expects w.m to be held.
+func (w *whileState[T]) mutScan(n uint64) (uint64, bool) {
+	if w.end || w.checked >= n {
+		return w.checked, w.end
+	}
+	_, rest := w.s.Split(w.checked)
+	rest.Iterate(func(e T) bool {
+		if !w.f(e) {
+			w.end = true
+			return false
+		}
+		w.checked++
+		return w.checked < n
+	})
+	if w.checked < n {
+		// Either f failed or rest ended.
+		w.end = true
+	}
+	return w.checked, w.end
+}
+
+// check reports whether element i, with value e, satisfies f,
+// running f only if it has not yet been run on element i.
+func (w *whileState[T]) check(i uint64, e T) bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if i < w.checked {
+		return true
+	}
+	if w.end {
+		return false
+	}
+	if i > w.checked {
+		// We can't evaluate elements out of order.
+		c, _ := w.mutScan(i + 1)
+		return i < c
+	}
+	if !w.f(e) {
+		w.end = true
+		return false
+	}
+	w.checked++
+	return true
+}
+
+// takeWhileSeq is the elements of st.s from off that satisfy st.f.
+// Seqs split or taken from a takeWhileSeq share its whileState, so the
+// results of f are shared between them.
+type takeWhileSeq[T any] struct {
+	st    *whileState[T]
+	off   uint64
+	limit uint64
+}
+
+func (t *takeWhileSeq[T]) Elem(i uint64) (T, bool) {
+	if t.limit > 0 && i >= t.limit {
+		var ret T
+		return ret, false
+	}
+	if c, _ := t.st.scan(t.off + i + 1); t.off+i >= c {
+		var ret T
+		return ret, false
+	}
+	return t.st.s.Elem(t.off + i)
+}
+
+func (t *takeWhileSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	if n == 0 {
+		return (*Vec[T])(nil), t
+	}
+	if t.limit > 0 && n >= t.limit {
+		return t, (*Vec[T])(nil)
+	}
+	c, _ := t.st.scan(t.off + n)
+	if c < t.off+n {
+		return t.Take(c - t.off), (*Vec[T])(nil)
+	}
+
+	var lim uint64
+	if t.limit > 0 {
+		lim = t.limit - n
+	}
+	r := &takeWhileSeq[T]{
+		st:    t.st,
+		off:   t.off + n,
+		limit: lim,
+	}
+	return t.Take(n), r
+}
+
+func (t *takeWhileSeq[T]) Take(n uint64) Seq[T] {
+	if n == 0 {
+		return (*Vec[T])(nil)
+	}
+	lim := n
+	if t.limit > 0 && t.limit < n {
+		lim = t.limit
+	}
+	return &takeWhileSeq[T]{
+		st:    t.st,
+		off:   t.off,
+		limit: lim,
+	}
+}
+
+// rest returns st.s starting at t.off.
+func (t *takeWhileSeq[T]) rest() Seq[T] {
+	if t.off == 0 {
+		return t.st.s
+	}
+	_, r := t.st.s.Split(t.off)
+	return r
+}
+
+func (t *takeWhileSeq[T]) Iterate(f func(T) bool) {
+	var i uint64
+	t.rest().Iterate(func(e T) bool {
+		if (t.limit > 0 && i == t.limit) || !t.st.check(t.off+i, e) {
+			return false
+		}
+		i++
+		return f(e)
+	})
+}
+
+func (t *takeWhileSeq[T]) Lazy(f func(func() T) bool) {
+	// We need to know each element satisfies the predicate
+	// before handing it out, so the elements are realized here.
+	var i uint64
+	t.rest().Iterate(func(e T) bool {
+		if (t.limit > 0 && i == t.limit) || !t.st.check(t.off+i, e) {
+			return false
+		}
+		i++
+		return f(func() T { return e })
+	})
+}
+
+// TakeWhile returns a Seq[T] containing the leading elements of `s` for
+// which `f` returns true. The resulting Seq ends just before the first
+// element of `s` for which `f` returns false.
+//
+// TakeWhile is lazy, so it is safe to use on unbounded sequences. The
+// results of `f` are remembered, so `f` is run at most once on each element
+// of `s`, no matter how the resulting Seq is accessed. Combined with Memo,
+// this means neither the elements of `s` nor `f` are computed more than once.
+func TakeWhile[T any](s Seq[T], f func(T) bool) Seq[T] {
+	return &takeWhileSeq[T]{
+		st: &whileState[T]{
+			s: s,
+			f: f,
+		},
+	}
+}
+
+// deferredSeq is a Seq whose contents are computed by mk the first time
+// they are needed.
+type deferredSeq[T any] struct {
+	once sync.Once
+	mk   func() Seq[T]
+	s    Seq[T]
+}
+
+func (d *deferredSeq[T]) get() Seq[T] {
+	d.once.Do(func() {
+		d.s = d.mk()
+		d.mk = nil
+	})
+	return d.s
+}
+
+func (d *deferredSeq[T]) Elem(i uint64) (T, bool) {
+	return d.get().Elem(i)
+}
+
+func (d *deferredSeq[T]) Split(n uint64) (Seq[T], Seq[T]) {
+	return d.get().Split(n)
+}
+
+func (d *deferredSeq[T]) Take(n uint64) Seq[T] {
+	return d.get().Take(n)
+}
+
+func (d *deferredSeq[T]) Iterate(f func(T) bool) {
+	d.get().Iterate(f)
+}
+
+func (d *deferredSeq[T]) Lazy(f func(func() T) bool) {
+	d.get().Lazy(f)
+}
+
+// DropWhile returns a Seq[T] containing the elements of `s` starting with
+// the first element for which `f` returns false.
+//
+// DropWhile is lazy. `f` is not run until an element of the resulting Seq
+// is requested, at which point `f` is run over the leading elements of `s`
+// until it returns false. If `f` never returns false for an unbounded `s`,
+// accessing the resulting Seq will never terminate.
+func DropWhile[T any](s Seq[T], f func(T) bool) Seq[T] {
+	st := &whileState[T]{
+		s: s,
+		f: f,
+	}
+	return &deferredSeq[T]{
+		mk: func() Seq[T] {
+			c, _ := st.scan(math.MaxUint64)
+			_, r := s.Split(c)
+			return r
+		},
+	}
+}
+
+// SkipUntil returns a Seq[T] containing the elements of `s` starting with
+// the first element for which `f` returns true.
+//
+// SkipUntil(s, f) is equivalent to
+//
+//	DropWhile(s, not(f))
+//
+// Like DropWhile, it is not computed until it is accessed, and if `f` never
+// returns true for an unbounded `s`, accessing it will never terminate.
+func SkipUntil[T any](s Seq[T], f func(T) bool) Seq[T] {
+	return DropWhile(s, func(e T) bool { return !f(e) })
+}
+
+// SplitWhen splits `s` before the first element for which `f` returns true.
+// It returns a Seq containing the elements before that element, and a Seq
+// containing that element and the remainder of `s`.
+//
+// SplitWhen(s, f) gives the same results as
+//
+//	TakeWhile(s, not(f)), DropWhile(s, not(f))
+//
+// but both halves share the results of `f`, so `f` is run at most once on each
+// element of `s`. Like DropWhile, the second Seq is not computed until it is
+// accessed.
+func SplitWhen[T any](s Seq[T], f func(T) bool) (Seq[T], Seq[T]) {
+	st := &whileState[T]{
+		s: s,
+		f: func(e T) bool { return !f(e) },
+	}
+	l := &takeWhileSeq[T]{
+		st: st,
+	}
+	r := &deferredSeq[T]{
+		mk: func() Seq[T] {
+			c, _ := st.scan(math.MaxUint64)
+			_, r := s.Split(c)
+			return r
+		},
+	}
+	return l, r
+}
+
+// Drop returns a Seq[T] containing the elements of `s` after the first `n`.
+//
+// Drop(s, n) is equivalent to the second Seq returned by s.Split(n), but
+// it does not construct the first, and is not computed until the resulting
+// Seq is accessed.
+func Drop[T any](s Seq[T], n uint64) Seq[T] {
+	if v, ok := s.(*Vec[T]); ok {
+		return v.drop(n)
+	}
+	return &deferredSeq[T]{
+		mk: func() Seq[T] {
+			_, r := s.Split(n)
+			return r
+		},
+	}
+}
+
+// drop returns a Vec containing all but the first idx elements of s.
+// It is like split, but does not construct the left Vec.
+func (s *Vec[T]) drop(idx uint64) *Vec[T] {
+	if s == nil {
+		return nil
+	}
+	if idx >= s.leftCount {
+		idx -= s.leftCount
+		if s.r == nil {
+			return nil
+		}
+		switch o := s.r.(type) {
+		case *Vec[T]:
+			return o.drop(idx)
+		case *seqLeaf[T]:
+			if idx >= uint64(len(o.seq)) {
+				return nil
+			}
+			ro := o.clone()
+			ro.mutCutFront(idx)
+			return &Vec[T]{
+				leftCount: uint64(len(ro.seq)),
+				height:    1,
+				l:         ro,
+			}
+		default:
+			panic("Bad Type")
+		}
+	}
+	if idx == 0 {
+		return s
+	}
+	switch o := s.l.(type) {
+	case *Vec[T]:
+		s = s.duplicate()
+		s.l = o.drop(idx)
+		s.leftCount -= idx
+		return s
+	case *seqLeaf[T]:
+		right := s.duplicate()
+		ro := o.clone()
+		ro.mutCutFront(idx)
+		right.l = ro
+		right.leftCount = uint64(len(ro.seq))
+		return right
+	default:
+		panic("Bad Type")
+	}
+}
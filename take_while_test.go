@@ -0,0 +1,190 @@
+package ion
+
+import (
+	"testing"
+)
+
+func TestTakeWhile(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, TakeWhile(From[int](0, 1), func(i int) bool {
+			return i >= 0
+		}))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, TakeWhile(From[int](0, 1), func(i int) bool {
+			return i < 10000
+		}))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		testFinSeq(t, TakeWhile(From[int](0, 1), func(i int) bool {
+			return i < 20000
+		}).Take(10000))
+	})
+	t.Run("fin3", func(t *testing.T) {
+		var i int
+		testFinSeq(t, TakeWhile(StateGen(func() (int, bool) {
+			ret := i
+			i++
+			return ret, true
+		}), func(i int) bool {
+			return i < 10000
+		}))
+	})
+	t.Run("once", func(t *testing.T) {
+		calls := make(map[int]int)
+		s := TakeWhile(Memo(From[int](0, 1)), func(i int) bool {
+			calls[i]++
+			return i < 100
+		})
+		s.Elem(50)
+		s.Iterate(Always(func(int) {}))
+		l, r := s.Split(20)
+		ToSlice(l)
+		ToSlice(r)
+		s.Elem(99)
+		s.Elem(100)
+		for i, c := range calls {
+			if c != 1 {
+				t.Fatalf("Expected f to be called once on %d, but was called %d times", i, c)
+			}
+		}
+		if len(calls) != 101 {
+			t.Fatalf("Expected f to be called on 101 elements, but was called on %d", len(calls))
+		}
+	})
+	t.Run("split-shares", func(t *testing.T) {
+		// The halves of a Split must share the results of f past the
+		// split point, whichever is accessed first.
+		calls := make(map[int]int)
+		s := TakeWhile(From[int](0, 1), func(i int) bool {
+			calls[i]++
+			return i < 100
+		})
+		_, r := s.Split(20)
+		_, rr := r.Split(10)
+		if e, ok := rr.Elem(50); !ok || e != 80 {
+			t.Fatalf("Expected rr[50] == 80, but got %d", e)
+		}
+		if e, ok := s.Elem(90); !ok || e != 90 {
+			t.Fatalf("Expected s[90] == 90, but got %d", e)
+		}
+		if n := len(ToSlice(r)); n != 80 {
+			t.Fatalf("Expected 80 elements, but got %d", n)
+		}
+		if _, ok := r.Elem(80); ok {
+			t.Fatalf("Expected no element at r[80]")
+		}
+		for i, c := range calls {
+			if c != 1 {
+				t.Fatalf("Expected f to be called once on %d, but was called %d times", i, c)
+			}
+		}
+	})
+}
+
+func TestSkipUntil(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Map(SkipUntil(From[int](0, 1), func(i int) bool {
+			return i >= 100
+		}), func(i int) int { return i - 100 }))
+	})
+	t.Run("fin", func(t *testing.T) {
+		testFinSeq(t, SkipUntil(From[int](-50, 1).Take(10050), func(i int) bool {
+			return i == 0
+		}))
+	})
+	t.Run("never", func(t *testing.T) {
+		s := SkipUntil(From[int](0, 1).Take(100), func(i int) bool {
+			return false
+		})
+		if _, ok := s.Elem(0); ok {
+			t.Fatalf("Expected an empty Seq")
+		}
+	})
+	t.Run("lazy", func(t *testing.T) {
+		var calls int
+		s := SkipUntil(From[int](0, 1), func(i int) bool {
+			calls++
+			return i == 10
+		})
+		if calls != 0 {
+			t.Fatalf("Expected SkipUntil not to run f until accessed")
+		}
+		if e, ok := s.Elem(5); !ok || e != 15 {
+			t.Fatalf("Expected s[5] == 15, but got %d", e)
+		}
+		if calls != 11 {
+			t.Fatalf("Expected f to be called 11 times, but was called %d times", calls)
+		}
+	})
+}
+
+func TestDropWhile(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Map(DropWhile(From[int](0, 1), func(i int) bool {
+			return i < 100
+		}), func(i int) int { return i - 100 }))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, Map(DropWhile(From[int](0, 1).Take(10100), func(i int) bool {
+			return i < 100
+		}), func(i int) int { return i - 100 }))
+	})
+	t.Run("all", func(t *testing.T) {
+		s := DropWhile(From[int](0, 1).Take(100), func(i int) bool {
+			return true
+		})
+		if e, ok := s.Elem(0); ok {
+			t.Fatalf("Expected s[0] to return no value, but got %d", e)
+		}
+	})
+}
+
+func TestSplitWhen(t *testing.T) {
+	var calls int
+	l, r := SplitWhen(From[int](0, 1), func(i int) bool {
+		calls++
+		return i == 10000
+	})
+	if calls != 0 {
+		t.Fatalf("Expected SplitWhen to be lazy, but f was called %d times", calls)
+	}
+	t.Run("left", func(t *testing.T) {
+		testFinSeq(t, l)
+	})
+	t.Run("right", func(t *testing.T) {
+		testInfSeq(t, Map(r, func(i int) int { return i - 10000 }))
+	})
+	if calls != 10001 {
+		t.Fatalf("Expected f to be called 10001 times, but was called %d times", calls)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Map(Drop(From[int](0, 1), 100), func(i int) int { return i - 100 }))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, Drop(Concat(From[int](-100, 1).Take(100), From[int](0, 1).Take(10000)), 100))
+	})
+	t.Run("vec", func(t *testing.T) {
+		v := BuildVec(func(add func(int)) {
+			for i := -1234; i < 10000; i++ {
+				add(i)
+			}
+		})
+		testFinSeq(t, Drop[int](v, 1234))
+		for _, n := range []uint64{0, 1, 63, 64, 65, 1000, 11233, 11234, 20000} {
+			want := ToSlice(Drop(From[int](-1234, 1).Take(11234), n))
+			got := ToSlice(Drop[int](v, n))
+			if len(got) != len(want) {
+				t.Fatalf("Drop(%d): expected %d elements, but got %d", n, len(want), len(got))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("Drop(%d): expected [%d] == %d, but was %d", n, i, want[i], got[i])
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package ion
+
+// treeNode is implemented by the nodes of the ordered trees, allowing
+// read-only operations on AVLTree, RBTree and their Func variants to
+// share an implementation.
+//
+// A treeNode may wrap a nil tree pointer, in which case empty returns
+// true and the other methods must not be called.
+type treeNode[T, U any] interface {
+	empty() bool
+	key() T
+	value() U
+	left() treeNode[T, U]
+	right() treeNode[T, U]
+	size() uint64
+}
+
+func (t *AVLTree[T, U]) empty() bool           { return t == nil }
+func (t *AVLTree[T, U]) key() T                { return t.k }
+func (t *AVLTree[T, U]) value() U              { return t.v }
+func (t *AVLTree[T, U]) left() treeNode[T, U]  { return t.l }
+func (t *AVLTree[T, U]) right() treeNode[T, U] { return t.r }
+func (t *AVLTree[T, U]) size() uint64          { return t.Size() }
+
+func (r *RBTree[T, U]) empty() bool           { return r == nil }
+func (r *RBTree[T, U]) key() T                { return r.k }
+func (r *RBTree[T, U]) value() U              { return r.v }
+func (r *RBTree[T, U]) left() treeNode[T, U]  { return r.l }
+func (r *RBTree[T, U]) right() treeNode[T, U] { return r.r }
+func (r *RBTree[T, U]) size() uint64          { return r.Size() }
+
+// nth returns the key and value of the i'th smallest key in the tree.
+func nth[T, U any](n treeNode[T, U], i uint64) (T, U, bool) {
+	for !n.empty() {
+		ls := n.left().size()
+		switch {
+		case i < ls:
+			n = n.left()
+		case i == ls:
+			return n.key(), n.value(), true
+		default:
+			i -= ls + 1
+			n = n.right()
+		}
+	}
+	var k T
+	var v U
+	return k, v, false
+}
+
+// reverseNodes executes f over the key/value pairs in the tree in
+// descending order, until f returns false. It returns false if f did.
+func reverseNodes[T, U any](n treeNode[T, U], f func(T, U) bool) bool {
+	if n.empty() {
+		return true
+	}
+	return reverseNodes(n.right(), f) && f(n.key(), n.value()) && reverseNodes(n.left(), f)
+}
+
+// iterateFrom executes f over the key/value pairs in the tree in
+// ascending order, beginning with the i'th smallest, until f returns
+// false. It returns false if f did.
+func iterateFrom[T, U any](n treeNode[T, U], i uint64, f func(T, U) bool) bool {
+	if n.empty() {
+		return true
+	}
+	ls := n.left().size()
+	if i > ls {
+		return iterateFrom(n.right(), i-ls-1, f)
+	}
+	if i < ls {
+		if !iterateFrom(n.left(), i, f) {
+			return false
+		}
+	}
+	if !f(n.key(), n.value()) {
+		return false
+	}
+	return iterateFrom(n.right(), 0, f)
+}
+
+// rank returns the number of keys in the tree less than k, or less
+// than or equal to k if inclusive is true, according to compare.
+func rank[T, U any](n treeNode[T, U], compare func(T, T) int, k T, inclusive bool) uint64 {
+	var r uint64
+	for !n.empty() {
+		if c := compare(n.key(), k); c < 0 || (inclusive && c == 0) {
+			r += n.left().size() + 1
+			n = n.right()
+		} else {
+			n = n.left()
+		}
+	}
+	return r
+}
+
+// minNode returns the smallest key in the tree and its value.
+func minNode[T, U any](n treeNode[T, U]) (T, U, bool) {
+	if n.empty() {
+		var k T
+		var v U
+		return k, v, false
+	}
+	for !n.left().empty() {
+		n = n.left()
+	}
+	return n.key(), n.value(), true
+}
+
+// maxNode returns the largest key in the tree and its value.
+func maxNode[T, U any](n treeNode[T, U]) (T, U, bool) {
+	if n.empty() {
+		var k T
+		var v U
+		return k, v, false
+	}
+	for !n.right().empty() {
+		n = n.right()
+	}
+	return n.key(), n.value(), true
+}
+
+// floor returns the largest key in the tree less than k, or less than
+// or equal to k if inclusive is true, along with its value.
+func floor[T, U any](n treeNode[T, U], compare func(T, T) int, k T, inclusive bool) (T, U, bool) {
+	var found treeNode[T, U]
+	for !n.empty() {
+		if c := compare(n.key(), k); c < 0 || (inclusive && c == 0) {
+			found = n
+			n = n.right()
+		} else {
+			n = n.left()
+		}
+	}
+	if found == nil {
+		var k T
+		var v U
+		return k, v, false
+	}
+	return found.key(), found.value(), true
+}
+
+// ceiling returns the smallest key in the tree greater than k, or
+// greater than or equal to k if inclusive is true, along with its value.
+func ceiling[T, U any](n treeNode[T, U], compare func(T, T) int, k T, inclusive bool) (T, U, bool) {
+	var found treeNode[T, U]
+	for !n.empty() {
+		if c := compare(n.key(), k); c > 0 || (inclusive && c == 0) {
+			found = n
+			n = n.left()
+		} else {
+			n = n.right()
+		}
+	}
+	if found == nil {
+		var k T
+		var v U
+		return k, v, false
+	}
+	return found.key(), found.value(), true
+}
@@ -0,0 +1,116 @@
+package ion
+
+import (
+	"cmp"
+	"math/bits"
+	"slices"
+)
+
+// dedupSorted checks that the keys of `es` are in ascending order and
+// removes duplicates in place. Where a key appears more than once, the last
+// entry is kept, as it would be by repeated calls to Insert.
+func dedupSorted[T cmp.Ordered, U any](name string, es []Pair[T, U]) []Pair[T, U] {
+	if len(es) == 0 {
+		return nil
+	}
+	j := 0
+	for i := 1; i < len(es); i++ {
+		switch {
+		case es[i].First == es[j].First:
+			es[j] = es[i]
+		case es[i].First < es[j].First:
+			panic(name + ": keys are not in ascending order")
+		default:
+			j++
+			es[j] = es[i]
+		}
+	}
+	return es[:j+1]
+}
+
+// sortEntries collects `s` into a slice sorted by key. Entries with equal
+// keys keep their original order.
+func sortEntries[T cmp.Ordered, U any](s Seq[Pair[T, U]]) []Pair[T, U] {
+	es := ToSlice(s)
+	slices.SortStableFunc(es, func(a, b Pair[T, U]) int {
+		return cmp.Compare(a.First, b.First)
+	})
+	return es
+}
+
+// AVLTreeFromSorted builds an AVLTree from `s`, which must be finite and
+// sorted in ascending order by key. If a key appears more than once, the
+// last value wins. AVLTreeFromSorted panics if `s` is not sorted.
+//
+// The tree is built in O(n) time and is perfectly balanced, which is much
+// faster than inserting the elements one at a time.
+func AVLTreeFromSorted[T cmp.Ordered, U any](s Seq[Pair[T, U]]) *AVLTree[T, U] {
+	return buildAVL(dedupSorted("AVLTreeFromSorted", ToSlice(s)))
+}
+
+// AVLTreeFromSeq builds an AVLTree from `s`, which must be finite. If a key
+// appears more than once, the last value wins. The elements are sorted
+// first, so AVLTreeFromSeq runs in O(n log n) time, but it still avoids the
+// allocation and rebalancing of repeated calls to Insert.
+func AVLTreeFromSeq[T cmp.Ordered, U any](s Seq[Pair[T, U]]) *AVLTree[T, U] {
+	return buildAVL(dedupSorted("AVLTreeFromSeq", sortEntries(s)))
+}
+
+func buildAVL[T cmp.Ordered, U any](es []Pair[T, U]) *AVLTree[T, U] {
+	if len(es) == 0 {
+		return nil
+	}
+	m := len(es) / 2
+	t := &AVLTree[T, U]{
+		k: es[m].First,
+		v: es[m].Second,
+		l: buildAVL(es[:m]),
+		r: buildAVL(es[m+1:]),
+	}
+	t.reheight()
+	return t
+}
+
+// RBTreeFromSorted builds an RBTree from `s`, which must be finite and
+// sorted in ascending order by key. If a key appears more than once, the
+// last value wins. RBTreeFromSorted panics if `s` is not sorted.
+//
+// The tree is built in O(n) time and is perfectly balanced, which is much
+// faster than inserting the elements one at a time.
+func RBTreeFromSorted[T cmp.Ordered, U any](s Seq[Pair[T, U]]) *RBTree[T, U] {
+	return buildRBTree(dedupSorted("RBTreeFromSorted", ToSlice(s)))
+}
+
+// RBTreeFromSeq builds an RBTree from `s`, which must be finite. If a key
+// appears more than once, the last value wins. The elements are sorted
+// first, so RBTreeFromSeq runs in O(n log n) time, but it still avoids the
+// allocation and rebalancing of repeated calls to Insert.
+func RBTreeFromSeq[T cmp.Ordered, U any](s Seq[Pair[T, U]]) *RBTree[T, U] {
+	return buildRBTree(dedupSorted("RBTreeFromSeq", sortEntries(s)))
+}
+
+func buildRBTree[T cmp.Ordered, U any](es []Pair[T, U]) *RBTree[T, U] {
+	// Every level of the tree is full except possibly the deepest, so
+	// coloring the deepest level red gives every path the same number
+	// of black nodes.
+	return buildRB(es, 0, bits.Len(uint(len(es)))-1)
+}
+
+func buildRB[T cmp.Ordered, U any](es []Pair[T, U], depth, redDepth int) *RBTree[T, U] {
+	if len(es) == 0 {
+		return nil
+	}
+	m := len(es) / 2
+	t := &RBTree[T, U]{
+		k:     es[m].First,
+		v:     es[m].Second,
+		c:     black,
+		count: uint64(len(es)),
+		l:     buildRB(es[:m], depth+1, redDepth),
+		r:     buildRB(es[m+1:], depth+1, redDepth),
+	}
+	if depth > 0 && depth == redDepth {
+		t.c = red
+	}
+	return t
+}
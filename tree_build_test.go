@@ -0,0 +1,116 @@
+package ion
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTreeFromSorted(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 7, 8, 100, 1023, 1024, 10000} {
+		var s Seq[Pair[uint64, uint64]] = (*Vec[Pair[uint64, uint64]])(nil)
+		if n > 0 {
+			s = Map(From[uint64](0, 2).Take(uint64(n)), func(k uint64) Pair[uint64, uint64] {
+				return Pair[uint64, uint64]{k, k * 10}
+			})
+		}
+		avl := AVLTreeFromSorted(s)
+		rb := RBTreeFromSorted(s)
+		if avl.Size() != uint64(n) || rb.Size() != uint64(n) {
+			t.Fatalf("n=%d: Expected size %d, but got %d (avl) and %d (rb)", n, n, avl.Size(), rb.Size())
+		}
+		if c := checkHeight(t, avl); c != nil {
+			t.Fatalf("n=%d: Invalid AVL height at node %d", n, c.k)
+		}
+		if c := checkBalance(t, avl); c != nil {
+			t.Fatalf("n=%d: Invalid AVL balance at node %d", n, c.k)
+		}
+		if c := validateRBTree(rb); c != nil || !noRedRed(rb) {
+			t.Fatalf("n=%d: Invalid RB tree", n)
+		}
+		if _, ok := checkAVLCounts(avl); !ok {
+			t.Fatalf("n=%d: Inconsistent AVL counts", n)
+		}
+		if _, ok := checkRBCounts(rb); !ok {
+			t.Fatalf("n=%d: Inconsistent RB counts", n)
+		}
+		for i := 0; i < n; i++ {
+			k := uint64(i * 2)
+			if v, ok := avl.Get(k); !ok || v != k*10 {
+				t.Fatalf("avl: Expected %d => %d, but got %d", k, k*10, v)
+			}
+			if v, ok := rb.Get(k); !ok || v != k*10 {
+				t.Fatalf("rb: Expected %d => %d, but got %d", k, k*10, v)
+			}
+		}
+
+		// The trees must remain valid through later changes.
+		for i := 0; i < n; i++ {
+			k := uint64(rand.Intn(n * 2))
+			if i%2 == 0 {
+				avl, _ = avl.Delete(k)
+				rb, _ = rb.Delete(k)
+			} else {
+				avl = avl.Insert(k, k)
+				rb = rb.Insert(k, k)
+			}
+		}
+		if c := checkBalance(t, avl); c != nil {
+			t.Fatalf("n=%d: Invalid AVL balance at node %d after changes", n, c.k)
+		}
+		if c := validateRBTree(rb); c != nil || !noRedRed(rb) {
+			t.Fatalf("n=%d: Invalid RB tree after changes", n)
+		}
+	}
+}
+
+func TestTreeFromSortedDuplicates(t *testing.T) {
+	s := Map(From[int](0, 1).Take(30), func(i int) Pair[int, int] {
+		return Pair[int, int]{i / 3, i}
+	})
+	avl := AVLTreeFromSorted(s)
+	rb := RBTreeFromSorted(s)
+	if avl.Size() != 10 || rb.Size() != 10 {
+		t.Fatalf("Expected 10 elements, but got %d (avl) and %d (rb)", avl.Size(), rb.Size())
+	}
+	for k := 0; k < 10; k++ {
+		if v, _ := avl.Get(k); v != k*3+2 {
+			t.Fatalf("avl: Expected the last value %d for %d, but got %d", k*3+2, k, v)
+		}
+		if v, _ := rb.Get(k); v != k*3+2 {
+			t.Fatalf("rb: Expected the last value %d for %d, but got %d", k*3+2, k, v)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Expected unsorted input to panic")
+		}
+	}()
+	AVLTreeFromSorted(Map(From[int](10, -1).Take(10), func(i int) Pair[int, int] {
+		return Pair[int, int]{i, i}
+	}))
+}
+
+func TestTreeFromSeq(t *testing.T) {
+	var ref *AVLTree[int, int]
+	var es []Pair[int, int]
+	for i := 0; i < 5000; i++ {
+		k := rand.Intn(1000)
+		es = append(es, Pair[int, int]{k, i})
+		ref = ref.Insert(k, i)
+	}
+	s := Map(From[int](0, 1).Take(uint64(len(es))), func(i int) Pair[int, int] { return es[i] })
+	avl := AVLTreeFromSeq(s)
+	rb := RBTreeFromSeq(s)
+	expect := ToSlice(ref.Entries())
+	for _, got := range [][]Pair[int, int]{ToSlice(avl.Entries()), ToSlice(rb.Entries())} {
+		if len(got) != len(expect) {
+			t.Fatalf("Expected %d entries, but got %d", len(expect), len(got))
+		}
+		for i := range got {
+			if got[i] != expect[i] {
+				t.Fatalf("Expected %v at %d, but got %v", expect[i], i, got[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ion
+
+import (
+	"cmp"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func checkAVLFunc[T, U any](n *avlFuncNode[T, U]) bool {
+	if n == nil {
+		return true
+	}
+	if n.height != max(n.l.ht(), n.r.ht())+1 || n.count != n.l.size()+n.r.size()+1 {
+		return false
+	}
+	if d := n.l.ht() - n.r.ht(); d > 1 || d < -1 {
+		return false
+	}
+	return checkAVLFunc(n.l) && checkAVLFunc(n.r)
+}
+
+// checkRBFunc returns the black height of n, or -1 if n is not a valid
+// red-black tree.
+func checkRBFunc[T, U any](n *rbFuncNode[T, U]) int {
+	if n == nil {
+		return 0
+	}
+	if n.c == red && (n.l.nodeColor() == red || n.r.nodeColor() == red) {
+		return -1
+	}
+	if n.count != n.l.size()+n.r.size()+1 {
+		return -1
+	}
+	l, r := checkRBFunc(n.l), checkRBFunc(n.r)
+	if l < 0 || l != r {
+		return -1
+	}
+	if n.c == black {
+		return l + 1
+	}
+	return l
+}
+
+func TestTreeFunc(t *testing.T) {
+	// Order by descending key, to make sure the comparator is actually used.
+	desc := func(a, b int) int { return cmp.Compare(b, a) }
+	avl := NewAVLTreeFunc[int, int](desc)
+	rb := NewRBTreeFunc[int, int](desc)
+	var ref *AVLTree[int, int]
+	for i := 0; i < 20000; i++ {
+		k := rand.Intn(2000)
+		if rand.Intn(3) == 0 {
+			var aok, rok, ok bool
+			avl, aok = avl.Delete(k)
+			rb, rok = rb.Delete(k)
+			ref, ok = ref.Delete(k)
+			if aok != ok || rok != ok {
+				t.Fatalf("Delete(%d): Expected %t, but got %t (avl) and %t (rb)", k, ok, aok, rok)
+			}
+		} else {
+			avl = avl.Insert(k, i)
+			rb = rb.Insert(k, i)
+			ref = ref.Insert(k, i)
+		}
+		if i%1000 == 0 {
+			if !checkAVLFunc(avl.root) {
+				t.Fatalf("Invalid AVL tree")
+			}
+			if h := checkRBFunc(rb.root.t); h != rb.root.h {
+				t.Fatalf("Invalid RB tree: black height %d, expected %d", h, rb.root.h)
+			}
+		}
+	}
+	if avl.Size() != ref.Size() || rb.Size() != ref.Size() {
+		t.Fatalf("Expected size %d, but got %d (avl) and %d (rb)", ref.Size(), avl.Size(), rb.Size())
+	}
+	ref.Reverse(func(k, v int) bool {
+		if av, ok := avl.Get(k); !ok || av != v {
+			t.Fatalf("avl: Expected %d => %d, but got %d", k, v, av)
+		}
+		if rv, ok := rb.Get(k); !ok || rv != v {
+			t.Fatalf("rb: Expected %d => %d, but got %d", k, v, rv)
+		}
+		return true
+	})
+
+	// The trees are in descending order.
+	keys := ToSlice(ref.Keys())
+	for i, k := range ToSlice(avl.Keys()) {
+		if e := keys[len(keys)-1-i]; k != e {
+			t.Fatalf("avl: Expected key %d at %d, but got %d", e, i, k)
+		}
+	}
+	for i, k := range ToSlice(rb.Keys()) {
+		if e := keys[len(keys)-1-i]; k != e {
+			t.Fatalf("rb: Expected key %d at %d, but got %d", e, i, k)
+		}
+	}
+	if k, _, _ := avl.Min(); k != keys[len(keys)-1] {
+		t.Fatalf("Expected Min() == %d, but got %d", keys[len(keys)-1], k)
+	}
+	if k, _, _ := rb.Floor(1000); k < 1000 {
+		t.Fatalf("Expected descending Floor(1000) >= 1000, but got %d", k)
+	}
+}
+
+func TestTreeFuncStrings(t *testing.T) {
+	fold := func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) }
+	avl := NewAVLTreeFunc[string, int](fold)
+	rb := NewRBTreeFunc[string, int](fold)
+	for i, s := range []string{"Banana", "apple", "CHERRY", "Apple", "banana"} {
+		avl = avl.Insert(s, i)
+		rb = rb.Insert(s, i)
+	}
+	expect := []Pair[string, int]{{"Apple", 3}, {"banana", 4}, {"CHERRY", 2}}
+	for _, got := range [][]Pair[string, int]{ToSlice(avl.Entries()), ToSlice(rb.Entries())} {
+		if len(got) != len(expect) {
+			t.Fatalf("Expected %v, but got %v", expect, got)
+		}
+		for i := range got {
+			if got[i] != expect[i] {
+				t.Fatalf("Expected %v, but got %v", expect, got)
+			}
+		}
+	}
+	if v, ok := rb.Get("cherry"); !ok || v != 2 {
+		t.Fatalf("Expected cherry => 2, but got %d", v)
+	}
+	if n := ToSlice(avl.Range("apple", "BANANA", Inclusive)); len(n) != 2 {
+		t.Fatalf("Expected 2 entries in range, but got %v", n)
+	}
+}
+
+func TestTreeFuncSetOps(t *testing.T) {
+	type point struct{ x, y int }
+	byXY := func(a, b point) int {
+		if c := cmp.Compare(a.x, b.x); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.y, b.y)
+	}
+	a := NewRBTreeFunc[point, int](byXY)
+	b := NewRBTreeFunc[point, int](byXY)
+	aa := NewAVLTreeFunc[point, int](byXY)
+	ab := NewAVLTreeFunc[point, int](byXY)
+	for x := 0; x < 30; x++ {
+		for y := 0; y < 30; y++ {
+			if (x+y)%2 == 0 {
+				a = a.Insert(point{x, y}, 1)
+				aa = aa.Insert(point{x, y}, 1)
+			}
+			if x%3 == 0 {
+				b = b.Insert(point{x, y}, 2)
+				ab = ab.Insert(point{x, y}, 2)
+			}
+		}
+	}
+	sum := func(_ point, a, b int) int { return a + b }
+	// |a| = 450, |b| = 300, |a & b| = 150
+	if n := a.Union(b, sum).Size(); n != 600 {
+		t.Fatalf("Expected union of 600, but got %d", n)
+	}
+	if n := aa.Intersection(ab, sum).Size(); n != 150 {
+		t.Fatalf("Expected intersection of 150, but got %d", n)
+	}
+	if v, _ := aa.Intersection(ab, sum).Get(point{3, 3}); v != 3 {
+		t.Fatalf("Expected resolved value 3, but got %d", v)
+	}
+	if n := a.Difference(b).Size(); n != 300 {
+		t.Fatalf("Expected difference of 300, but got %d", n)
+	}
+	if n := aa.SymmetricDifference(ab).Size(); n != 450 {
+		t.Fatalf("Expected symmetric difference of 450, but got %d", n)
+	}
+	if h := checkRBFunc(a.Union(b, sum).root.t); h < 0 {
+		t.Fatalf("Invalid RB tree")
+	}
+	if !checkAVLFunc(aa.Union(ab, nil).root) {
+		t.Fatalf("Invalid AVL tree")
+	}
+}
@@ -0,0 +1,151 @@
+package ion
+
+import (
+	"cmp"
+)
+
+// Bounds specifies which ends of a range are included in it.
+type Bounds uint8
+
+const (
+	// IncludeLow ranges include the lower endpoint: [lo, hi)
+	IncludeLow Bounds = 1 << iota
+	// IncludeHigh ranges include the upper endpoint: (lo, hi]
+	IncludeHigh
+	// Exclusive ranges include neither endpoint: (lo, hi)
+	Exclusive Bounds = 0
+	// Inclusive ranges include both endpoints: [lo, hi]
+	Inclusive = IncludeLow | IncludeHigh
+)
+
+// treeRange returns a treeSeq over the entries of the tree with keys
+// between lo and hi.
+func treeRange[T, U any](n treeNode[T, U], compare func(T, T) int, lo, hi T, b Bounds) Seq[Pair[T, U]] {
+	start := rank(n, compare, lo, b&IncludeLow == 0)
+	end := rank(n, compare, hi, b&IncludeHigh != 0)
+	if end < start {
+		end = start
+	}
+	return &treeSeq[T, U, Pair[T, U]]{
+		t:    n,
+		proj: MakePair[T, U],
+		lo:   start,
+		hi:   end,
+	}
+}
+
+// Min returns the smallest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (t *AVLTree[T, U]) Min() (T, U, bool) {
+	return minNode[T, U](t)
+}
+
+// Max returns the largest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (t *AVLTree[T, U]) Max() (T, U, bool) {
+	return maxNode[T, U](t)
+}
+
+// Floor returns the largest key in the tree less than or equal to `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTree[T, U]) Floor(k T) (T, U, bool) {
+	return floor[T, U](t, cmp.Compare[T], k, true)
+}
+
+// Ceiling returns the smallest key in the tree greater than or equal to
+// `k`, and its value. If there is no such key, it returns false.
+func (t *AVLTree[T, U]) Ceiling(k T) (T, U, bool) {
+	return ceiling[T, U](t, cmp.Compare[T], k, true)
+}
+
+// Lower returns the largest key in the tree strictly less than `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTree[T, U]) Lower(k T) (T, U, bool) {
+	return floor[T, U](t, cmp.Compare[T], k, false)
+}
+
+// Higher returns the smallest key in the tree strictly greater than `k`,
+// and its value. If there is no such key, it returns false.
+func (t *AVLTree[T, U]) Higher(k T) (T, U, bool) {
+	return ceiling[T, U](t, cmp.Compare[T], k, false)
+}
+
+// Range returns a Seq of the key/value pairs in the tree with keys
+// between `lo` and `hi`, in ascending key order. `b` determines whether
+// `lo` and `hi` themselves are included in the range.
+//
+// The returned Seq is lazy and shares the tree's memory.
+func (t *AVLTree[T, U]) Range(lo, hi T, b Bounds) Seq[Pair[T, U]] {
+	return treeRange[T, U](t, cmp.Compare[T], lo, hi, b)
+}
+
+// Min returns the smallest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (r *RBTree[T, U]) Min() (T, U, bool) {
+	return minNode[T, U](r)
+}
+
+// Max returns the largest key in the tree and its value.
+// If the tree is empty, it returns false.
+func (r *RBTree[T, U]) Max() (T, U, bool) {
+	return maxNode[T, U](r)
+}
+
+// Floor returns the largest key in the tree less than or equal to `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTree[T, U]) Floor(k T) (T, U, bool) {
+	return floor[T, U](r, cmp.Compare[T], k, true)
+}
+
+// Ceiling returns the smallest key in the tree greater than or equal to
+// `k`, and its value. If there is no such key, it returns false.
+func (r *RBTree[T, U]) Ceiling(k T) (T, U, bool) {
+	return ceiling[T, U](r, cmp.Compare[T], k, true)
+}
+
+// Lower returns the largest key in the tree strictly less than `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTree[T, U]) Lower(k T) (T, U, bool) {
+	return floor[T, U](r, cmp.Compare[T], k, false)
+}
+
+// Higher returns the smallest key in the tree strictly greater than `k`,
+// and its value. If there is no such key, it returns false.
+func (r *RBTree[T, U]) Higher(k T) (T, U, bool) {
+	return ceiling[T, U](r, cmp.Compare[T], k, false)
+}
+
+// Range returns a Seq of the key/value pairs in the tree with keys
+// between `lo` and `hi`, in ascending key order. `b` determines whether
+// `lo` and `hi` themselves are included in the range.
+//
+// The returned Seq is lazy and shares the tree's memory.
+func (r *RBTree[T, U]) Range(lo, hi T, b Bounds) Seq[Pair[T, U]] {
+	return treeRange[T, U](r, cmp.Compare[T], lo, hi, b)
+}
+
+// Rank returns the number of keys in the tree less than `k`.
+// Rank runs in O(log n) time.
+func (t *AVLTree[T, U]) Rank(k T) uint64 {
+	return rank[T, U](t, cmp.Compare[T], k, false)
+}
+
+// Select returns the `i`th smallest key in the tree, starting from 0,
+// and its value. If the tree has `i` or fewer keys, it returns false.
+// Select runs in O(log n) time.
+func (t *AVLTree[T, U]) Select(i uint64) (T, U, bool) {
+	return nth[T, U](t, i)
+}
+
+// Rank returns the number of keys in the tree less than `k`.
+// Rank runs in O(log n) time.
+func (r *RBTree[T, U]) Rank(k T) uint64 {
+	return rank[T, U](r, cmp.Compare[T], k, false)
+}
+
+// Select returns the `i`th smallest key in the tree, starting from 0,
+// and its value. If the tree has `i` or fewer keys, it returns false.
+// Select runs in O(log n) time.
+func (r *RBTree[T, U]) Select(i uint64) (T, U, bool) {
+	return nth[T, U](r, i)
+}
@@ -0,0 +1,206 @@
+package ion
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// queryTree is the query interface shared by AVLTree and RBTree.
+type queryTree interface {
+	Min() (int, int, bool)
+	Max() (int, int, bool)
+	Floor(int) (int, int, bool)
+	Ceiling(int) (int, int, bool)
+	Lower(int) (int, int, bool)
+	Higher(int) (int, int, bool)
+	Range(int, int, Bounds) Seq[Pair[int, int]]
+}
+
+func testTreeQuery(t *testing.T, tr queryTree) {
+	// The tree contains the even numbers in [0, 2000), mapped to their halves.
+	check := func(name string, q func(int) (int, int, bool), k, expect int, exists bool) {
+		t.Helper()
+		rk, rv, ok := q(k)
+		if ok != exists {
+			t.Fatalf("%s(%d): Expected found == %t, but got %t", name, k, exists, ok)
+		}
+		if ok && (rk != expect || rv != expect/2) {
+			t.Fatalf("%s(%d): Expected (%d, %d), but got (%d, %d)", name, k, expect, expect/2, rk, rv)
+		}
+	}
+	check("Min", func(int) (int, int, bool) { return tr.Min() }, 0, 0, true)
+	check("Max", func(int) (int, int, bool) { return tr.Max() }, 0, 1998, true)
+	// brute finds the first key in order (ascending, or descending if
+	// rev is set) satisfying f.
+	brute := func(rev bool, f func(int) bool) (int, bool) {
+		for i := 0; i < 1000; i++ {
+			k := i * 2
+			if rev {
+				k = 1998 - i*2
+			}
+			if f(k) {
+				return k, true
+			}
+		}
+		return 0, false
+	}
+	for k := -3; k < 2003; k++ {
+		e, ok := brute(true, func(x int) bool { return x <= k })
+		check("Floor", tr.Floor, k, e, ok)
+		e, ok = brute(true, func(x int) bool { return x < k })
+		check("Lower", tr.Lower, k, e, ok)
+		e, ok = brute(false, func(x int) bool { return x >= k })
+		check("Ceiling", tr.Ceiling, k, e, ok)
+		e, ok = brute(false, func(x int) bool { return x > k })
+		check("Higher", tr.Higher, k, e, ok)
+	}
+
+	bounds := []Bounds{Exclusive, IncludeLow, IncludeHigh, Inclusive}
+	for _, b := range bounds {
+		for i := 0; i < 200; i++ {
+			lo := rand.Intn(2100) - 50
+			hi := lo + rand.Intn(300) - 50
+			t.Run(fmt.Sprintf("range-%d-%d-%d", lo, hi, b), func(t *testing.T) {
+				var expect []int
+				for k := 0; k < 2000; k += 2 {
+					if (k > lo || (b&IncludeLow != 0 && k == lo)) &&
+						(k < hi || (b&IncludeHigh != 0 && k == hi)) {
+						expect = append(expect, k)
+					}
+				}
+				r := tr.Range(lo, hi, b)
+				got := ToSlice(Map(r, func(p Pair[int, int]) int { return p.First }))
+				if fmt.Sprint(got) != fmt.Sprint(expect) {
+					t.Fatalf("Expected %v, but got %v", expect, got)
+				}
+				if len(expect) > 0 {
+					if p, ok := r.Elem(uint64(len(expect) - 1)); !ok || p.First != expect[len(expect)-1] {
+						t.Fatalf("Expected last element %d, but got %v", expect[len(expect)-1], p)
+					}
+				}
+				if _, ok := r.Elem(uint64(len(expect))); ok {
+					t.Fatalf("Expected no element past the end of the range")
+				}
+			})
+		}
+	}
+}
+
+func TestTreeQuery(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range rand.Perm(1000) {
+		avl = avl.Insert(i*2, i)
+		rb = rb.Insert(i*2, i)
+	}
+	t.Run("avl", func(t *testing.T) {
+		testTreeQuery(t, avl)
+	})
+	t.Run("rb", func(t *testing.T) {
+		testTreeQuery(t, rb)
+	})
+	t.Run("empty", func(t *testing.T) {
+		var avl *AVLTree[int, int]
+		var rb *RBTree[int, int]
+		if _, _, ok := avl.Min(); ok {
+			t.Fatalf("Expected no minimum in an empty tree")
+		}
+		if _, _, ok := rb.Floor(10); ok {
+			t.Fatalf("Expected no floor in an empty tree")
+		}
+		if n := len(ToSlice(rb.Range(0, 10, Inclusive))); n != 0 {
+			t.Fatalf("Expected an empty range, but got %d elements", n)
+		}
+	})
+}
+
+func TestTreeRankSelect(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range rand.Perm(1000) {
+		avl = avl.Insert(i*2, i)
+		rb = rb.Insert(i*2, i)
+	}
+	for k := -1; k < 2001; k++ {
+		expect := uint64((k + 1) / 2)
+		if k < 0 {
+			expect = 0
+		}
+		if r := avl.Rank(k); r != expect {
+			t.Fatalf("avl: Expected Rank(%d) == %d, but got %d", k, expect, r)
+		}
+		if r := rb.Rank(k); r != expect {
+			t.Fatalf("rb: Expected Rank(%d) == %d, but got %d", k, expect, r)
+		}
+	}
+	for i := uint64(0); i < 1001; i++ {
+		ak, av, aok := avl.Select(i)
+		rk, rv, rok := rb.Select(i)
+		if i == 1000 {
+			if aok || rok {
+				t.Fatalf("Expected no element at index 1000")
+			}
+			continue
+		}
+		if !aok || ak != int(i*2) || av != int(i) {
+			t.Fatalf("avl: Expected Select(%d) == (%d, %d), but got (%d, %d)", i, i*2, i, ak, av)
+		}
+		if !rok || rk != int(i*2) || rv != int(i) {
+			t.Fatalf("rb: Expected Select(%d) == (%d, %d), but got (%d, %d)", i, i*2, i, rk, rv)
+		}
+	}
+}
+
+func checkAVLCounts[T cmp.Ordered, U any](t *AVLTree[T, U]) (uint64, bool) {
+	if t == nil {
+		return 0, true
+	}
+	l, lok := checkAVLCounts(t.l)
+	r, rok := checkAVLCounts(t.r)
+	return l + r + 1, lok && rok && t.count == l+r+1
+}
+
+func checkRBCounts[T cmp.Ordered, U any](t *RBTree[T, U]) (uint64, bool) {
+	if t == nil {
+		return 0, true
+	}
+	l, lok := checkRBCounts(t.l)
+	r, rok := checkRBCounts(t.r)
+	return l + r + 1, lok && rok && t.count == l+r+1
+}
+
+func TestTreeSize(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	var avls []*AVLTree[int, int]
+	var rbs []*RBTree[int, int]
+	var sizes []uint64
+	present := make(map[int]bool)
+	for i := 0; i < 5000; i++ {
+		k := rand.Intn(1000)
+		if rand.Intn(3) == 0 {
+			avl, _ = avl.Delete(k)
+			rb, _ = rb.Delete(k)
+			delete(present, k)
+		} else {
+			avl = avl.Insert(k, k)
+			rb = rb.Insert(k, k)
+			present[k] = true
+		}
+		avls = append(avls, avl)
+		rbs = append(rbs, rb)
+		sizes = append(sizes, uint64(len(present)))
+	}
+	// Check every version, to make sure later operations didn't
+	// modify earlier trees.
+	for i := range sizes {
+		if n, ok := checkAVLCounts(avls[i]); !ok || n != sizes[i] || avls[i].Size() != sizes[i] {
+			t.Fatalf("avl %d: Expected size %d, but got %d (%d nodes, consistent: %t)", i, sizes[i], avls[i].Size(), n, ok)
+		}
+		if n, ok := checkRBCounts(rbs[i]); !ok || n != sizes[i] || rbs[i].Size() != sizes[i] {
+			t.Fatalf("rb %d: Expected size %d, but got %d (%d nodes, consistent: %t)", i, sizes[i], rbs[i].Size(), n, ok)
+		}
+	}
+}
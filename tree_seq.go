@@ -0,0 +1,156 @@
+package ion
+
+import (
+	"math"
+)
+
+// treeSeq is a Seq over the entries of an ordered tree, with indices
+// in [lo, hi). Each entry is converted to an element with proj.
+// Because the trees track their sizes, Elem runs in O(log n) time and
+// Split and Take in constant time.
+type treeSeq[T, U, E any] struct {
+	t    treeNode[T, U]
+	proj func(T, U) E
+	lo   uint64
+	hi   uint64
+}
+
+func (s *treeSeq[T, U, E]) Elem(i uint64) (E, bool) {
+	if i >= s.hi-s.lo {
+		var ret E
+		return ret, false
+	}
+	k, v, ok := nth(s.t, s.lo+i)
+	if !ok {
+		var ret E
+		return ret, false
+	}
+	return s.proj(k, v), true
+}
+
+func (s *treeSeq[T, U, E]) Split(n uint64) (Seq[E], Seq[E]) {
+	if n >= s.hi-s.lo {
+		return s, (*Vec[E])(nil)
+	}
+	l := &treeSeq[T, U, E]{
+		t:    s.t,
+		proj: s.proj,
+		lo:   s.lo,
+		hi:   s.lo + n,
+	}
+	r := &treeSeq[T, U, E]{
+		t:    s.t,
+		proj: s.proj,
+		lo:   s.lo + n,
+		hi:   s.hi,
+	}
+	return l, r
+}
+
+func (s *treeSeq[T, U, E]) Take(n uint64) Seq[E] {
+	if n >= s.hi-s.lo {
+		return s
+	}
+	return &treeSeq[T, U, E]{
+		t:    s.t,
+		proj: s.proj,
+		lo:   s.lo,
+		hi:   s.lo + n,
+	}
+}
+
+func (s *treeSeq[T, U, E]) Iterate(f func(E) bool) {
+	i := s.lo
+	iterateFrom(s.t, s.lo, func(k T, v U) bool {
+		if i == s.hi {
+			return false
+		}
+		i++
+		return f(s.proj(k, v))
+	})
+}
+
+func (s *treeSeq[T, U, E]) Lazy(f func(func() E) bool) {
+	i := s.lo
+	iterateFrom(s.t, s.lo, func(k T, v U) bool {
+		if i == s.hi {
+			return false
+		}
+		i++
+		return f(func() E { return s.proj(k, v) })
+	})
+}
+
+func newTreeSeq[T, U, E any](t treeNode[T, U], proj func(T, U) E) Seq[E] {
+	return &treeSeq[T, U, E]{
+		t:    t,
+		proj: proj,
+		hi:   math.MaxUint64,
+	}
+}
+
+func keyOf[T, U any](k T, _ U) T {
+	return k
+}
+
+func valueOf[T, U any](_ T, v U) U {
+	return v
+}
+
+// Iterate executes `f` over the key/value pairs in the tree in ascending
+// key order, until `f` returns false.
+func (t *AVLTree[T, U]) Iterate(f func(T, U) bool) {
+	t.iterate(f)
+}
+
+// Reverse executes `f` over the key/value pairs in the tree in descending
+// key order, until `f` returns false.
+func (t *AVLTree[T, U]) Reverse(f func(T, U) bool) {
+	t.reverse(f)
+}
+
+// Keys returns a Seq containing the keys of the tree in ascending order.
+func (t *AVLTree[T, U]) Keys() Seq[T] {
+	return newTreeSeq[T, U](t, keyOf[T, U])
+}
+
+// Values returns a Seq containing the values of the tree, in ascending
+// order of their keys.
+func (t *AVLTree[T, U]) Values() Seq[U] {
+	return newTreeSeq[T, U](t, valueOf[T, U])
+}
+
+// Entries returns a Seq containing the key/value pairs of the tree in
+// ascending key order. Elem on the returned Seq runs in O(log n) time.
+func (t *AVLTree[T, U]) Entries() Seq[Pair[T, U]] {
+	return newTreeSeq[T, U](t, MakePair[T, U])
+}
+
+// Iterate executes `f` over the key/value pairs in the tree in ascending
+// key order, until `f` returns false.
+func (r *RBTree[T, U]) Iterate(f func(T, U) bool) {
+	r.iterate(f)
+}
+
+// Reverse executes `f` over the key/value pairs in the tree in descending
+// key order, until `f` returns false.
+func (r *RBTree[T, U]) Reverse(f func(T, U) bool) {
+	r.reverse(f)
+}
+
+// Keys returns a Seq containing the keys of the tree in ascending order.
+func (r *RBTree[T, U]) Keys() Seq[T] {
+	return newTreeSeq[T, U](r, keyOf[T, U])
+}
+
+// Values returns a Seq containing the values of the tree, in ascending
+// order of their keys.
+func (r *RBTree[T, U]) Values() Seq[U] {
+	return newTreeSeq[T, U](r, valueOf[T, U])
+}
+
+// Entries returns a Seq containing the key/value pairs of the tree in
+// ascending key order. Elem on the returned Seq runs in O(log n) time.
+func (r *RBTree[T, U]) Entries() Seq[Pair[T, U]] {
+	return newTreeSeq[T, U](r, MakePair[T, U])
+}
@@ -0,0 +1,101 @@
+package ion
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTreeSeq(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range rand.Perm(10000) {
+		avl = avl.Insert(i, i*2)
+		rb = rb.Insert(i, i*2)
+	}
+
+	half := func(i int) int { return i / 2 }
+	first := func(p Pair[int, int]) int { return p.First }
+	second := func(p Pair[int, int]) int { return p.Second / 2 }
+
+	t.Run("avl-keys", func(t *testing.T) {
+		testFinSeq(t, avl.Keys())
+	})
+	t.Run("avl-values", func(t *testing.T) {
+		testFinSeq(t, Map(avl.Values(), half))
+	})
+	t.Run("avl-entries", func(t *testing.T) {
+		testFinSeq(t, Map(avl.Entries(), first))
+		testFinSeq(t, Map(avl.Entries(), second))
+	})
+	t.Run("rb-keys", func(t *testing.T) {
+		testFinSeq(t, rb.Keys())
+	})
+	t.Run("rb-values", func(t *testing.T) {
+		testFinSeq(t, Map(rb.Values(), half))
+	})
+	t.Run("rb-entries", func(t *testing.T) {
+		testFinSeq(t, Map(rb.Entries(), first))
+		testFinSeq(t, Map(rb.Entries(), second))
+	})
+	t.Run("empty", func(t *testing.T) {
+		if n := len(ToSlice((*AVLTree[int, int])(nil).Keys())); n != 0 {
+			t.Fatalf("Expected no keys, but got %d", n)
+		}
+		if n := len(ToSlice((*RBTree[int, int])(nil).Keys())); n != 0 {
+			t.Fatalf("Expected no keys, but got %d", n)
+		}
+	})
+}
+
+func TestTreeReverse(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range rand.Perm(1000) {
+		avl = avl.Insert(i, -i)
+		rb = rb.Insert(i, -i)
+	}
+	check := func(t *testing.T, rev func(func(int, int) bool)) {
+		next := 999
+		rev(func(k, v int) bool {
+			if k != next || v != -next {
+				t.Fatalf("Expected (%d, %d), but got (%d, %d)", next, -next, k, v)
+			}
+			next--
+			return next >= 500
+		})
+		if next != 499 {
+			t.Fatalf("Expected to stop at 499, but stopped at %d", next)
+		}
+	}
+	t.Run("avl", func(t *testing.T) {
+		check(t, avl.Reverse)
+	})
+	t.Run("rb", func(t *testing.T) {
+		check(t, rb.Reverse)
+	})
+}
+
+func TestTreeDeleteValues(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for _, i := range rand.Perm(2000) {
+		avl = avl.Insert(i, i*2)
+		rb = rb.Insert(i, i*2)
+	}
+	for _, i := range rand.Perm(2000)[:1000] {
+		avl, _ = avl.Delete(i)
+		rb, _ = rb.Delete(i)
+	}
+	avl.Iterate(func(k, v int) bool {
+		if v != k*2 {
+			t.Fatalf("avl: Expected %d => %d, but was %d", k, k*2, v)
+		}
+		return true
+	})
+	rb.Iterate(func(k, v int) bool {
+		if v != k*2 {
+			t.Fatalf("rb: Expected %d => %d, but was %d", k, k*2, v)
+		}
+		return true
+	})
+}
@@ -0,0 +1,487 @@
+package ion
+
+import (
+	"cmp"
+)
+
+// joiner implements the set operations on a balanced tree with node type
+// N in terms of two primitives: expose, which breaks a non-empty tree
+// into its root and subtrees, and join, which builds a balanced tree
+// from two trees and a key that falls between them. Keys are ordered by
+// compare.
+//
+// The algorithms follow Blelloch, Ferizovic and Sun, "Just Join for
+// Parallel Ordered Sets". Union, intersection and difference of trees
+// of sizes m <= n run in O(m log(n/m + 1)) time, and subtrees which are
+// not affected by the operation are shared with the inputs.
+type joiner[T, U any, N comparable] struct {
+	compare func(T, T) int
+	expose  func(N) (N, T, U, N)
+	join    func(N, T, U, N) N
+}
+
+func (j joiner[T, U, N]) empty(t N) bool {
+	var zero N
+	return t == zero
+}
+
+// split splits t into the trees of keys less than and greater than k.
+// If k is present in t, it also returns its value.
+func (j joiner[T, U, N]) split(t N, k T) (N, bool, U, N) {
+	if j.empty(t) {
+		var v U
+		return t, false, v, t
+	}
+	l, tk, tv, r := j.expose(t)
+	switch c := j.compare(k, tk); {
+	case c < 0:
+		ll, found, v, lr := j.split(l, k)
+		return ll, found, v, j.join(lr, tk, tv, r)
+	case c > 0:
+		rl, found, v, rr := j.split(r, k)
+		return j.join(l, tk, tv, rl), found, v, rr
+	}
+	return l, true, tv, r
+}
+
+// insert returns t with the key k set to v.
+func (j joiner[T, U, N]) insert(t N, k T, v U) N {
+	if j.empty(t) {
+		return j.join(t, k, v, t)
+	}
+	l, tk, tv, r := j.expose(t)
+	switch c := j.compare(k, tk); {
+	case c < 0:
+		return j.join(j.insert(l, k, v), tk, tv, r)
+	case c > 0:
+		return j.join(l, tk, tv, j.insert(r, k, v))
+	}
+	return j.join(l, k, v, r)
+}
+
+// delete returns t without the key k, and whether k was present.
+func (j joiner[T, U, N]) delete(t N, k T) (N, bool) {
+	if j.empty(t) {
+		return t, false
+	}
+	l, tk, tv, r := j.expose(t)
+	switch c := j.compare(k, tk); {
+	case c < 0:
+		if nl, ok := j.delete(l, k); ok {
+			return j.join(nl, tk, tv, r), true
+		}
+		return t, false
+	case c > 0:
+		if nr, ok := j.delete(r, k); ok {
+			return j.join(l, tk, tv, nr), true
+		}
+		return t, false
+	}
+	return j.join2(l, r), true
+}
+
+// splitLast removes the largest key from the non-empty tree t, returning
+// the remaining tree and the removed key and value.
+func (j joiner[T, U, N]) splitLast(t N) (N, T, U) {
+	l, k, v, r := j.expose(t)
+	if j.empty(r) {
+		return l, k, v
+	}
+	rest, lk, lv := j.splitLast(r)
+	return j.join(l, k, v, rest), lk, lv
+}
+
+// join2 joins two trees where every key in l is less than every key in r.
+func (j joiner[T, U, N]) join2(l, r N) N {
+	if j.empty(l) {
+		return r
+	}
+	rest, k, v := j.splitLast(l)
+	return j.join(rest, k, v, r)
+}
+
+func (j joiner[T, U, N]) union(a, b N, resolve func(T, U, U) U) N {
+	if j.empty(a) {
+		return b
+	}
+	if j.empty(b) {
+		return a
+	}
+	al, k, v, ar := j.expose(a)
+	bl, found, bv, br := j.split(b, k)
+	l := j.union(al, bl, resolve)
+	r := j.union(ar, br, resolve)
+	if found {
+		return j.join(l, k, resolve(k, v, bv), r)
+	}
+	if l == al && r == ar {
+		return a
+	}
+	return j.join(l, k, v, r)
+}
+
+func (j joiner[T, U, N]) intersection(a, b N, resolve func(T, U, U) U) N {
+	if j.empty(a) {
+		return a
+	}
+	if j.empty(b) {
+		return b
+	}
+	al, k, v, ar := j.expose(a)
+	bl, found, bv, br := j.split(b, k)
+	l := j.intersection(al, bl, resolve)
+	r := j.intersection(ar, br, resolve)
+	if found {
+		return j.join(l, k, resolve(k, v, bv), r)
+	}
+	return j.join2(l, r)
+}
+
+func (j joiner[T, U, N]) difference(a, b N) N {
+	if j.empty(a) || j.empty(b) {
+		return a
+	}
+	al, k, v, ar := j.expose(a)
+	bl, found, _, br := j.split(b, k)
+	l := j.difference(al, bl)
+	r := j.difference(ar, br)
+	if found {
+		return j.join2(l, r)
+	}
+	if l == al && r == ar {
+		return a
+	}
+	return j.join(l, k, v, r)
+}
+
+func (j joiner[T, U, N]) symmetricDifference(a, b N) N {
+	if j.empty(a) {
+		return b
+	}
+	if j.empty(b) {
+		return a
+	}
+	al, k, v, ar := j.expose(a)
+	bl, found, _, br := j.split(b, k)
+	l := j.symmetricDifference(al, bl)
+	r := j.symmetricDifference(ar, br)
+	if found {
+		return j.join2(l, r)
+	}
+	return j.join(l, k, v, r)
+}
+
+// keepFirst is the default resolve function for Union and Intersection.
+func keepFirst[T, U any](_ T, a, _ U) U {
+	return a
+}
+
+// avlJoinNode is implemented by the node types of the AVL trees, so that
+// AVLTree and AVLTreeFunc share the code which joins them. N is the node
+// type itself. node builds a new node, and does not use its receiver, so
+// it may be called on a nil node.
+type avlJoinNode[T, U any, N any] interface {
+	comparable
+	ht() int8
+	expose() (N, T, U, N)
+	node(l N, k T, v U, r N) N
+}
+
+func (t *AVLTree[T, U]) ht() int8 {
+	if t == nil {
+		return 0
+	}
+	return t.height
+}
+
+func (t *AVLTree[T, U]) expose() (*AVLTree[T, U], T, U, *AVLTree[T, U]) {
+	return t.l, t.k, t.v, t.r
+}
+
+func (*AVLTree[T, U]) node(l *AVLTree[T, U], k T, v U, r *AVLTree[T, U]) *AVLTree[T, U] {
+	t := &AVLTree[T, U]{k: k, v: v, l: l, r: r}
+	t.reheight()
+	return t
+}
+
+func avlJoin[T, U any, N avlJoinNode[T, U, N]](l N, k T, v U, r N) N {
+	switch {
+	case l.ht() > r.ht()+1:
+		return avlJoinRight(l, k, v, r)
+	case r.ht() > l.ht()+1:
+		return avlJoinLeft(l, k, v, r)
+	}
+	return l.node(l, k, v, r)
+}
+
+func avlRotateLeft[T, U any, N avlJoinNode[T, U, N]](t N) N {
+	l, k, v, r := t.expose()
+	rl, rk, rv, rr := r.expose()
+	return t.node(t.node(l, k, v, rl), rk, rv, rr)
+}
+
+func avlRotateRight[T, U any, N avlJoinNode[T, U, N]](t N) N {
+	l, k, v, r := t.expose()
+	ll, lk, lv, lr := l.expose()
+	return t.node(ll, lk, lv, t.node(lr, k, v, r))
+}
+
+// avlJoinRight joins l, k and r, where l is taller than r, by descending
+// the right spine of l to find a subtree of about the same height as r.
+func avlJoinRight[T, U any, N avlJoinNode[T, U, N]](l N, k T, v U, r N) N {
+	ll, lk, lv, c := l.expose()
+	if c.ht() <= r.ht()+1 {
+		n := l.node(c, k, v, r)
+		if n.ht() <= ll.ht()+1 {
+			return l.node(ll, lk, lv, n)
+		}
+		return avlRotateLeft(l.node(ll, lk, lv, avlRotateRight(n)))
+	}
+	n := avlJoinRight(c, k, v, r)
+	t := l.node(ll, lk, lv, n)
+	if n.ht() <= ll.ht()+1 {
+		return t
+	}
+	return avlRotateLeft(t)
+}
+
+// avlJoinLeft is the mirror image of avlJoinRight, for when r is taller
+// than l.
+func avlJoinLeft[T, U any, N avlJoinNode[T, U, N]](l N, k T, v U, r N) N {
+	c, rk, rv, rr := r.expose()
+	if c.ht() <= l.ht()+1 {
+		n := r.node(l, k, v, c)
+		if n.ht() <= rr.ht()+1 {
+			return r.node(n, rk, rv, rr)
+		}
+		return avlRotateRight(r.node(avlRotateLeft(n), rk, rv, rr))
+	}
+	n := avlJoinLeft(l, k, v, c)
+	t := r.node(n, rk, rv, rr)
+	if n.ht() <= rr.ht()+1 {
+		return t
+	}
+	return avlRotateRight(t)
+}
+
+func avlJoiner[T cmp.Ordered, U any]() joiner[T, U, *AVLTree[T, U]] {
+	return joiner[T, U, *AVLTree[T, U]]{
+		compare: cmp.Compare[T],
+		expose:  (*AVLTree[T, U]).expose,
+		join:    avlJoin[T, U, *AVLTree[T, U]],
+	}
+}
+
+// Union returns a tree containing every key present in either `t` or `o`.
+// Keys present in both trees are given the value returned by `resolve`,
+// which is passed the key and the values from `t` and `o`, in that order.
+// If `resolve` is nil, the values from `t` are kept.
+func (t *AVLTree[T, U]) Union(o *AVLTree[T, U], resolve func(k T, a, b U) U) *AVLTree[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return avlJoiner[T, U]().union(t, o, resolve)
+}
+
+// Intersection returns a tree containing the keys present in both `t` and
+// `o`, with values given by `resolve`, which is passed the key and the
+// values from `t` and `o`, in that order. If `resolve` is nil, the values
+// from `t` are kept.
+func (t *AVLTree[T, U]) Intersection(o *AVLTree[T, U], resolve func(k T, a, b U) U) *AVLTree[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return avlJoiner[T, U]().intersection(t, o, resolve)
+}
+
+// Difference returns a tree containing the entries of `t` whose keys are
+// not present in `o`.
+func (t *AVLTree[T, U]) Difference(o *AVLTree[T, U]) *AVLTree[T, U] {
+	return avlJoiner[T, U]().difference(t, o)
+}
+
+// SymmetricDifference returns a tree containing the entries of `t` and `o`
+// whose keys are present in only one of the two trees.
+func (t *AVLTree[T, U]) SymmetricDifference(o *AVLTree[T, U]) *AVLTree[T, U] {
+	return avlJoiner[T, U]().symmetricDifference(t, o)
+}
+
+// rbh is the root of a red-black tree along with its black height, which
+// the set operations need to join trees. The black height of an empty tree
+// is 0.
+type rbh[N any] struct {
+	t N
+	h int
+}
+
+// rbJoinNode is implemented by the node types of the red-black trees, so
+// that RBTree and RBTreeFunc share the code which joins them. N is the node
+// type itself. nodeColor must return black for a nil node. node builds a
+// new node, and does not use its receiver, so it may be called on a nil
+// node.
+type rbJoinNode[T, U any, N any] interface {
+	comparable
+	nodeColor() color
+	expose() (N, T, U, N)
+	node(c color, l N, k T, v U, r N) N
+}
+
+func (r *RBTree[T, U]) blackHeight() int {
+	var h int
+	for ; r != nil; r = r.l {
+		if r.c == black {
+			h++
+		}
+	}
+	return h
+}
+
+func (r *RBTree[T, U]) nodeColor() color {
+	if r == nil {
+		return black
+	}
+	return r.c
+}
+
+func (r *RBTree[T, U]) expose() (*RBTree[T, U], T, U, *RBTree[T, U]) {
+	return r.l, r.k, r.v, r.r
+}
+
+func (*RBTree[T, U]) node(c color, l *RBTree[T, U], k T, v U, r *RBTree[T, U]) *RBTree[T, U] {
+	return &RBTree[T, U]{
+		c:     c,
+		count: l.Size() + r.Size() + 1,
+		k:     k,
+		v:     v,
+		l:     l,
+		r:     r,
+	}
+}
+
+// rbChildHeight returns the black height of the children of n, whose
+// black height is h.
+func rbChildHeight[T, U any, N rbJoinNode[T, U, N]](n N, h int) int {
+	if n.nodeColor() == black {
+		return h - 1
+	}
+	return h
+}
+
+func rbRecolor[T, U any, N rbJoinNode[T, U, N]](n N, c color) N {
+	l, k, v, r := n.expose()
+	return n.node(c, l, k, v, r)
+}
+
+func rbExpose[T, U any, N rbJoinNode[T, U, N]](t rbh[N]) (rbh[N], T, U, rbh[N]) {
+	h := rbChildHeight[T, U](t.t, t.h)
+	l, k, v, r := t.t.expose()
+	return rbh[N]{l, h}, k, v, rbh[N]{r, h}
+}
+
+func rbJoin[T, U any, N rbJoinNode[T, U, N]](l rbh[N], k T, v U, r rbh[N]) rbh[N] {
+	// Joining is simpler if both roots are black.
+	if l.t.nodeColor() == red {
+		l = rbh[N]{rbRecolor[T, U](l.t, black), l.h + 1}
+	}
+	if r.t.nodeColor() == red {
+		r = rbh[N]{rbRecolor[T, U](r.t, black), r.h + 1}
+	}
+	switch {
+	case l.h > r.h:
+		t := rbJoinRight(l.t, l.h, k, v, r.t, r.h)
+		if _, _, _, tr := t.expose(); t.nodeColor() == red && tr.nodeColor() == red {
+			return rbh[N]{rbRecolor[T, U](t, black), l.h + 1}
+		}
+		return rbh[N]{t, l.h}
+	case r.h > l.h:
+		t := rbJoinLeft(l.t, l.h, k, v, r.t, r.h)
+		if tl, _, _, _ := t.expose(); t.nodeColor() == red && tl.nodeColor() == red {
+			return rbh[N]{rbRecolor[T, U](t, black), r.h + 1}
+		}
+		return rbh[N]{t, r.h}
+	}
+	return rbh[N]{l.t.node(red, l.t, k, v, r.t), l.h}
+}
+
+// rbJoinRight joins l, k and r, where l has a greater black height than
+// r and r is black, by descending the right spine of l to find a black
+// subtree with the same black height as r. The result has the same black
+// height as l, but its root may be red with a red right child, which the
+// caller must fix.
+func rbJoinRight[T, U any, N rbJoinNode[T, U, N]](l N, lh int, k T, v U, r N, rh int) N {
+	if lh == rh && l.nodeColor() == black {
+		return l.node(red, l, k, v, r)
+	}
+	ll, lk, lv, lr := l.expose()
+	n := rbJoinRight(lr, rbChildHeight[T, U](l, lh), k, v, r, rh)
+	if l.nodeColor() == black && n.nodeColor() == red {
+		if nl, nk, nv, nr := n.expose(); nr.nodeColor() == red {
+			// Rotate left, turning the outer red node black.
+			return l.node(red, l.node(black, ll, lk, lv, nl), nk, nv, rbRecolor[T, U](nr, black))
+		}
+	}
+	return l.node(l.nodeColor(), ll, lk, lv, n)
+}
+
+// rbJoinLeft is the mirror image of rbJoinRight, for when r has a greater
+// black height than l.
+func rbJoinLeft[T, U any, N rbJoinNode[T, U, N]](l N, lh int, k T, v U, r N, rh int) N {
+	if lh == rh && r.nodeColor() == black {
+		return r.node(red, l, k, v, r)
+	}
+	rl, rk, rv, rr := r.expose()
+	n := rbJoinLeft(l, lh, k, v, rl, rbChildHeight[T, U](r, rh))
+	if r.nodeColor() == black && n.nodeColor() == red {
+		if nl, nk, nv, nr := n.expose(); nl.nodeColor() == red {
+			// Rotate right, turning the outer red node black.
+			return r.node(red, rbRecolor[T, U](nl, black), nk, nv, r.node(black, nr, rk, rv, rr))
+		}
+	}
+	return r.node(r.nodeColor(), n, rk, rv, rr)
+}
+
+func rbJoiner[T cmp.Ordered, U any]() joiner[T, U, rbh[*RBTree[T, U]]] {
+	return joiner[T, U, rbh[*RBTree[T, U]]]{
+		compare: cmp.Compare[T],
+		expose:  rbExpose[T, U, *RBTree[T, U]],
+		join:    rbJoin[T, U, *RBTree[T, U]],
+	}
+}
+
+func (r *RBTree[T, U]) withHeight() rbh[*RBTree[T, U]] {
+	return rbh[*RBTree[T, U]]{r, r.blackHeight()}
+}
+
+// Union returns a tree containing every key present in either `r` or `o`.
+// Keys present in both trees are given the value returned by `resolve`,
+// which is passed the key and the values from `r` and `o`, in that order.
+// If `resolve` is nil, the values from `r` are kept.
+func (r *RBTree[T, U]) Union(o *RBTree[T, U], resolve func(k T, a, b U) U) *RBTree[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return rbJoiner[T, U]().union(r.withHeight(), o.withHeight(), resolve).t
+}
+
+// Intersection returns a tree containing the keys present in both `r` and
+// `o`, with values given by `resolve`, which is passed the key and the
+// values from `r` and `o`, in that order. If `resolve` is nil, the values
+// from `r` are kept.
+func (r *RBTree[T, U]) Intersection(o *RBTree[T, U], resolve func(k T, a, b U) U) *RBTree[T, U] {
+	if resolve == nil {
+		resolve = keepFirst[T, U]
+	}
+	return rbJoiner[T, U]().intersection(r.withHeight(), o.withHeight(), resolve).t
+}
+
+// Difference returns a tree containing the entries of `r` whose keys are
+// not present in `o`.
+func (r *RBTree[T, U]) Difference(o *RBTree[T, U]) *RBTree[T, U] {
+	return rbJoiner[T, U]().difference(r.withHeight(), o.withHeight()).t
+}
+
+// SymmetricDifference returns a tree containing the entries of `r` and `o`
+// whose keys are present in only one of the two trees.
+func (r *RBTree[T, U]) SymmetricDifference(o *RBTree[T, U]) *RBTree[T, U] {
+	return rbJoiner[T, U]().symmetricDifference(r.withHeight(), o.withHeight()).t
+}
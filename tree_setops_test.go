@@ -0,0 +1,209 @@
+package ion
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func noRedRed[T cmp.Ordered, U any](t *RBTree[T, U]) bool {
+	if t == nil {
+		return true
+	}
+	if t.c == red && ((t.l != nil && t.l.c == red) || (t.r != nil && t.r.c == red)) {
+		return false
+	}
+	return noRedRed(t.l) && noRedRed(t.r)
+}
+
+func randomMap(n, max int) map[uint64]uint64 {
+	m := make(map[uint64]uint64)
+	for i := 0; i < n; i++ {
+		k := uint64(rand.Intn(max))
+		m[k] = k * 10
+	}
+	return m
+}
+
+func TestTreeSetOps(t *testing.T) {
+	sum := func(_ uint64, a, b uint64) uint64 { return a + b }
+	ops := []struct {
+		name   string
+		expect func(a, b map[uint64]uint64) map[uint64]uint64
+		avl    func(a, b *AVLTree[uint64, uint64]) *AVLTree[uint64, uint64]
+		rb     func(a, b *RBTree[uint64, uint64]) *RBTree[uint64, uint64]
+	}{
+		{
+			name: "union",
+			expect: func(a, b map[uint64]uint64) map[uint64]uint64 {
+				m := make(map[uint64]uint64)
+				for k, v := range a {
+					m[k] = v
+				}
+				for k, v := range b {
+					m[k] += v
+				}
+				return m
+			},
+			avl: func(a, b *AVLTree[uint64, uint64]) *AVLTree[uint64, uint64] { return a.Union(b, sum) },
+			rb:  func(a, b *RBTree[uint64, uint64]) *RBTree[uint64, uint64] { return a.Union(b, sum) },
+		},
+		{
+			name: "intersection",
+			expect: func(a, b map[uint64]uint64) map[uint64]uint64 {
+				m := make(map[uint64]uint64)
+				for k, v := range a {
+					if bv, ok := b[k]; ok {
+						m[k] = v + bv
+					}
+				}
+				return m
+			},
+			avl: func(a, b *AVLTree[uint64, uint64]) *AVLTree[uint64, uint64] { return a.Intersection(b, sum) },
+			rb:  func(a, b *RBTree[uint64, uint64]) *RBTree[uint64, uint64] { return a.Intersection(b, sum) },
+		},
+		{
+			name: "difference",
+			expect: func(a, b map[uint64]uint64) map[uint64]uint64 {
+				m := make(map[uint64]uint64)
+				for k, v := range a {
+					if _, ok := b[k]; !ok {
+						m[k] = v
+					}
+				}
+				return m
+			},
+			avl: func(a, b *AVLTree[uint64, uint64]) *AVLTree[uint64, uint64] { return a.Difference(b) },
+			rb:  func(a, b *RBTree[uint64, uint64]) *RBTree[uint64, uint64] { return a.Difference(b) },
+		},
+		{
+			name: "symmetric-difference",
+			expect: func(a, b map[uint64]uint64) map[uint64]uint64 {
+				m := make(map[uint64]uint64)
+				for k, v := range a {
+					if _, ok := b[k]; !ok {
+						m[k] = v
+					}
+				}
+				for k, v := range b {
+					if _, ok := a[k]; !ok {
+						m[k] = v
+					}
+				}
+				return m
+			},
+			avl: func(a, b *AVLTree[uint64, uint64]) *AVLTree[uint64, uint64] { return a.SymmetricDifference(b) },
+			rb:  func(a, b *RBTree[uint64, uint64]) *RBTree[uint64, uint64] { return a.SymmetricDifference(b) },
+		},
+	}
+
+	sizes := [][2]int{{0, 0}, {0, 100}, {100, 0}, {1, 1000}, {1000, 1}, {50, 5000}, {3000, 3000}, {5000, 20}}
+	for _, op := range ops {
+		for _, sz := range sizes {
+			t.Run(fmt.Sprintf("%s-%d-%d", op.name, sz[0], sz[1]), func(t *testing.T) {
+				am := randomMap(sz[0], 10000)
+				bm := randomMap(sz[1], 10000)
+				var avla, avlb *AVLTree[uint64, uint64]
+				var rba, rbb *RBTree[uint64, uint64]
+				for k, v := range am {
+					avla = avla.Insert(k, v)
+					rba = rba.Insert(k, v)
+				}
+				for k, v := range bm {
+					avlb = avlb.Insert(k, v)
+					rbb = rbb.Insert(k, v)
+				}
+				expect := op.expect(am, bm)
+
+				check := func(name string, size uint64, get func(uint64) (uint64, bool)) {
+					if size != uint64(len(expect)) {
+						t.Fatalf("%s: Expected %d elements, but got %d", name, len(expect), size)
+					}
+					for k, v := range expect {
+						if gv, ok := get(k); !ok || gv != v {
+							t.Fatalf("%s: Expected %d => %d, but got %d (%t)", name, k, v, gv, ok)
+						}
+					}
+				}
+
+				avl := op.avl(avla, avlb)
+				check("avl", avl.Size(), avl.Get)
+				if n := checkHeight(t, avl); n != nil {
+					t.Fatalf("avl: Bad height at node %v", n.k)
+				}
+				if n := checkBalance(t, avl); n != nil {
+					t.Fatalf("avl: Unbalanced at node %v", n.k)
+				}
+				if _, ok := checkAVLCounts(avl); !ok {
+					t.Fatalf("avl: Inconsistent sizes")
+				}
+
+				rb := op.rb(rba, rbb)
+				check("rb", rb.Size(), rb.Get)
+				if n := validateRBTree(rb); n != nil {
+					t.Fatalf("rb: Invalid tree at node %v", n.k)
+				}
+				if !noRedRed(rb) {
+					t.Fatalf("rb: Red node with red child")
+				}
+				if _, ok := checkRBCounts(rb); !ok {
+					t.Fatalf("rb: Inconsistent sizes")
+				}
+
+				// The result must remain usable by Insert and Delete.
+				for k := range expect {
+					if k%2 == 0 {
+						rb, _ = rb.Delete(k)
+					} else {
+						rb = rb.Insert(k+1, k)
+					}
+					if n := validateRBTree(rb); n != nil {
+						t.Fatalf("rb: Invalid tree at node %v after modification", n.k)
+					}
+				}
+
+				// The inputs must not have changed.
+				check2 := func(name string, m map[uint64]uint64, size uint64, get func(uint64) (uint64, bool)) {
+					if size != uint64(len(m)) {
+						t.Fatalf("%s: Input changed size from %d to %d", name, len(m), size)
+					}
+					for k, v := range m {
+						if gv, ok := get(k); !ok || gv != v {
+							t.Fatalf("%s: Input changed: Expected %d => %d, but got %d", name, k, v, gv)
+						}
+					}
+				}
+				check2("avl a", am, avla.Size(), avla.Get)
+				check2("avl b", bm, avlb.Size(), avlb.Get)
+				check2("rb a", am, rba.Size(), rba.Get)
+				check2("rb b", bm, rbb.Size(), rbb.Get)
+			})
+		}
+	}
+}
+
+func TestTreeSetOpsSharing(t *testing.T) {
+	var avla, avlb *AVLTree[int, int]
+	var rba, rbb *RBTree[int, int]
+	for i := 0; i < 1000; i++ {
+		avla = avla.Insert(i, i)
+		rba = rba.Insert(i, i)
+		avlb = avlb.Insert(i+5000, i)
+		rbb = rbb.Insert(i+5000, i)
+	}
+	if avla.Difference(avlb) != avla {
+		t.Fatalf("avl: Expected difference of disjoint trees to return the original tree")
+	}
+	if rba.Difference(rbb) != rba {
+		t.Fatalf("rb: Expected difference of disjoint trees to return the original tree")
+	}
+	if avla.Union(nil, nil) != avla || rba.Union(nil, nil) != rba {
+		t.Fatalf("Expected union with an empty tree to return the original tree")
+	}
+
+	u := rba.Union(rbb, nil)
+	if u.Size() != 2000 {
+		t.Fatalf("Expected 2000 elements, but got %d", u.Size())
+	}
+}
@@ -0,0 +1,116 @@
+package ion
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestTreeUpdate(t *testing.T) {
+	var avl *AVLTree[uint64, uint64]
+	var rb *RBTree[uint64, uint64]
+	ref := make(map[uint64]uint64)
+	for i := 0; i < 20000; i++ {
+		k := uint64(rand.Intn(1000))
+		op := rand.Intn(4)
+		f := func(old uint64, ok bool) (uint64, bool) {
+			if rv, rok := ref[k]; ok != rok || old != rv {
+				t.Fatalf("Update(%d): Expected to be called with (%d, %t), but got (%d, %t)", k, rv, rok, old, ok)
+			}
+			switch op {
+			case 0:
+				// Delete
+				return 0, false
+			case 1:
+				// Toggle: delete if present, otherwise insert 1
+				return 1, !ok
+			default:
+				// Increment, or insert 1
+				return old + 1, true
+			}
+		}
+		_, present := ref[k]
+		// Only deleting a missing key leaves the tree unchanged.
+		expect := op != 0 || present
+
+		na, aok := avl.Update(k, f)
+		nr, rok := rb.Update(k, f)
+		if aok != expect || rok != expect {
+			t.Fatalf("Update(%d): Expected changed == %t, but got %t (avl) and %t (rb)", k, expect, aok, rok)
+		}
+		if !expect && (na != avl || nr != rb) {
+			t.Fatalf("Update(%d): Expected the original tree when nothing changed", k)
+		}
+		avl, rb = na, nr
+		switch op {
+		case 0:
+			delete(ref, k)
+		case 1:
+			if present {
+				delete(ref, k)
+			} else {
+				ref[k] = 1
+			}
+		default:
+			ref[k]++
+		}
+
+		if i%500 == 0 {
+			if n := checkHeight(t, avl); n != nil {
+				t.Fatalf("Invalid AVL height at node %d", n.k)
+			}
+			if n := checkBalance(t, avl); n != nil {
+				t.Fatalf("Invalid AVL balance at node %d", n.k)
+			}
+			if n := validateRBTree(rb); n != nil || !noRedRed(rb) {
+				t.Fatalf("Invalid RB tree")
+			}
+			if n, ok := checkAVLCounts(avl); !ok || n != uint64(len(ref)) {
+				t.Fatalf("avl: Expected %d nodes, but got %d (consistent: %t)", len(ref), n, ok)
+			}
+			if n, ok := checkRBCounts(rb); !ok || n != uint64(len(ref)) {
+				t.Fatalf("rb: Expected %d nodes, but got %d (consistent: %t)", len(ref), n, ok)
+			}
+		}
+	}
+	for k, v := range ref {
+		if av, ok := avl.Get(k); !ok || av != v {
+			t.Fatalf("avl: Expected %d => %d, but got %d", k, v, av)
+		}
+		if rv, ok := rb.Get(k); !ok || rv != v {
+			t.Fatalf("rb: Expected %d => %d, but got %d", k, v, rv)
+		}
+	}
+	if avl.Size() != uint64(len(ref)) || rb.Size() != uint64(len(ref)) {
+		t.Fatalf("Expected size %d, but got %d (avl) and %d (rb)", len(ref), avl.Size(), rb.Size())
+	}
+}
+
+func TestTreeUpdatePersistent(t *testing.T) {
+	var avl *AVLTree[int, int]
+	var rb *RBTree[int, int]
+	for i := 0; i < 100; i++ {
+		avl = avl.Insert(i, i)
+		rb = rb.Insert(i, i)
+	}
+	double := func(old int, ok bool) (int, bool) { return old * 2, ok }
+	na, _ := avl.Update(50, double)
+	nr, _ := rb.Update(50, double)
+	if v, _ := na.Get(50); v != 100 {
+		t.Fatalf("avl: Expected 50 => 100, but got %d", v)
+	}
+	if v, _ := nr.Get(50); v != 100 {
+		t.Fatalf("rb: Expected 50 => 100, but got %d", v)
+	}
+	if v, _ := avl.Get(50); v != 50 {
+		t.Fatalf("avl: Expected the original tree to be unchanged, but got 50 => %d", v)
+	}
+	if v, _ := rb.Get(50); v != 50 {
+		t.Fatalf("rb: Expected the original tree to be unchanged, but got 50 => %d", v)
+	}
+	if na, ok := avl.Update(1000, double); ok || na != avl {
+		t.Fatalf("avl: Expected no change for a missing key")
+	}
+	if nr, ok := rb.Update(1000, double); ok || nr != rb {
+		t.Fatalf("rb: Expected no change for a missing key")
+	}
+}
@@ -479,6 +479,10 @@ func (t *Vec[T]) reheight() {
 
 // Append returns a new list containing the elements of the original Vec[T]
 // with i appended to the end.
+//
+// The last span of the Vec is copied, so Append costs up to spanSize
+// element copies on top of the O(log n) path copy. To build a Vec from
+// many elements, use BuildVec.
 func (s *Vec[T]) Append(i T) *Vec[T] {
 	//fmt.Printf("Appending %d\n", i)
 	if s == nil {
@@ -528,7 +532,9 @@ func (s *Vec[T]) Append(i T) *Vec[T] {
 				s.leftCount += spanSize
 				s.height = 2
 			} else {
+				o = o.clone()
 				o.seq = append(o.seq, i)
+				s.r = o
 			}
 		default:
 			panic("BAD TYPE")
@@ -306,3 +306,21 @@ func TestVecLeafCut(t *testing.T) {
 		t.Fatalf("cutting the leaf failed.")
 	}
 }
+
+func TestVecAppendPersistent(t *testing.T) {
+	var s *Vec[uint64]
+	for i := uint64(0); i < 70; i++ {
+		s = s.Append(i)
+	}
+	a := s.Append(100)
+	b := s.Append(200)
+	if s.Len() != 70 {
+		t.Fatalf("Expected s.Len() == 70, but was %d", s.Len())
+	}
+	if e, _ := a.Elem(70); e != 100 || a.Len() != 71 {
+		t.Fatalf("Expected a[70] == 100, but was %d", e)
+	}
+	if e, _ := b.Elem(70); e != 200 || b.Len() != 71 {
+		t.Fatalf("Expected b[70] == 200, but was %d", e)
+	}
+}
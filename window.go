@@ -0,0 +1,175 @@
+package ion
+
+type windowSeq[T any] struct {
+	s       Seq[T]
+	size    uint64
+	step    uint64
+	partial bool // include a final window containing < size elements
+	limit   uint64
+}
+
+// toVec returns the elements of s as a Vec.
+func toVec[T any](s Seq[T]) *Vec[T] {
+	if v, ok := s.(*Vec[T]); ok {
+		return v
+	}
+	return BuildVec(func(add func(T)) {
+		s.Iterate(Always(add))
+	})
+}
+
+func (w *windowSeq[T]) Elem(i uint64) (*Vec[T], bool) {
+	if w.limit > 0 && i >= w.limit {
+		return nil, false
+	}
+	v := toVec(Drop(w.s, i*w.step).Take(w.size))
+	if v.Len() == 0 || (!w.partial && v.Len() < w.size) {
+		return nil, false
+	}
+	return v, true
+}
+
+func (w *windowSeq[T]) Split(n uint64) (Seq[*Vec[T]], Seq[*Vec[T]]) {
+	if n == 0 {
+		return (*Vec[*Vec[T]])(nil), w
+	}
+	if w.limit > 0 && n >= w.limit {
+		return w, (*Vec[*Vec[T]])(nil)
+	}
+	var lim uint64
+	if w.limit > 0 {
+		lim = w.limit - n
+	}
+	r := &windowSeq[T]{
+		s:       Drop(w.s, n*w.step),
+		size:    w.size,
+		step:    w.step,
+		partial: w.partial,
+		limit:   lim,
+	}
+	return w.Take(n), r
+}
+
+func (w *windowSeq[T]) Take(n uint64) Seq[*Vec[T]] {
+	if n == 0 {
+		return (*Vec[*Vec[T]])(nil)
+	}
+	lim := n
+	if w.limit > 0 && w.limit < n {
+		lim = w.limit
+	}
+	return &windowSeq[T]{
+		s:       w.s,
+		size:    w.size,
+		step:    w.step,
+		partial: w.partial,
+		limit:   lim,
+	}
+}
+
+func (w *windowSeq[T]) Iterate(f func(*Vec[T]) bool) {
+	// buf holds the elements of the current window. Once it is full,
+	// we drop the first step elements to get the beginning of the next.
+	// Since Vecs are immutable, consecutive windows share most of their
+	// structure.
+	var (
+		buf  *Vec[T]
+		skip uint64
+		n    uint64
+		cont = true
+	)
+	emit := func() bool {
+		n++
+		return f(buf) && (w.limit == 0 || n < w.limit)
+	}
+	w.s.Iterate(func(e T) bool {
+		if skip > 0 {
+			skip--
+			return true
+		}
+		buf = buf.Append(e)
+		if buf.Len() == w.size {
+			if !emit() {
+				cont = false
+				return false
+			}
+			if w.step >= w.size {
+				skip = w.step - w.size
+				buf = nil
+			} else {
+				buf = buf.drop(w.step)
+			}
+		}
+		return true
+	})
+	if cont && w.partial && buf.Len() > 0 {
+		emit()
+	}
+}
+
+func (w *windowSeq[T]) Lazy(f func(func() *Vec[T]) bool) {
+	// Windows are built up from the ones before them, so
+	// we realize each window and return a closure that returns it.
+	w.Iterate(func(v *Vec[T]) bool {
+		return f(func() *Vec[T] { return v })
+	})
+}
+
+// Window returns a Seq of Vecs, each containing `size` consecutive elements
+// of `s`. The first window begins with the first element of `s`, and each
+// following window begins `step` elements after the one before it. If `step`
+// is less than `size`, the windows overlap, and if it is greater, elements
+// between the windows are skipped. Only complete windows are included, so
+// any trailing elements of `s` which do not fill a window are dropped.
+//
+// When iterating, consecutive windows are built from one another, so
+// overlapping windows share most of their structure.
+//
+// Window is lazy, so it is safe to use on unbounded sequences.
+// Window panics if `size` or `step` is 0.
+func Window[T any](s Seq[T], size, step uint64) Seq[*Vec[T]] {
+	if size == 0 || step == 0 {
+		panic("Window size and step must be > 0")
+	}
+	return &windowSeq[T]{
+		s:    s,
+		size: size,
+		step: step,
+	}
+}
+
+// Chunk returns a Seq of Vecs, each containing the next `n` elements of `s`.
+// If the number of elements in `s` is not a multiple of `n`, the final Vec
+// contains the remaining elements.
+//
+// Chunk is lazy, so it is safe to use on unbounded sequences.
+// Chunk panics if `n` is 0.
+//
+// For example, to process a stream of requests in batches of 100:
+//
+//	Chunk(requests, 100).Iterate(func(batch *Vec[Request]) bool {
+//		return handle(batch)
+//	})
+func Chunk[T any](s Seq[T], n uint64) Seq[*Vec[T]] {
+	if n == 0 {
+		panic("Chunk size must be > 0")
+	}
+	return &windowSeq[T]{
+		s:       s,
+		size:    n,
+		step:    n,
+		partial: true,
+	}
+}
+
+// Pairwise returns a Seq of Pairs of each element of `s` and the element
+// following it. Pairwise of a Seq with fewer than 2 elements is empty.
+//
+// Pairwise is lazy, so it is safe to use on unbounded sequences.
+func Pairwise[T any](s Seq[T]) Seq[Pair[T, T]] {
+	return Map(Window(s, 2, 1), func(v *Vec[T]) Pair[T, T] {
+		a, _ := v.Elem(0)
+		b, _ := v.Elem(1)
+		return Pair[T, T]{First: a, Second: b}
+	})
+}
@@ -0,0 +1,107 @@
+package ion
+
+import (
+	"testing"
+)
+
+func vecSeqs[T any](s Seq[*Vec[T]]) Seq[Seq[T]] {
+	return Map(s, func(v *Vec[T]) Seq[T] { return v })
+}
+
+func TestChunk(t *testing.T) {
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, Flatten(vecSeqs(Chunk(From[int](0, 1), 100))))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, Flatten(vecSeqs(Chunk(From[int](0, 1).Take(10000), 99))))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		var i int
+		l, _ := Chunk(StateGen(func() (int, bool) {
+			ret := i
+			i++
+			return ret, true
+		}), 1000).Split(10)
+		testFinSeq(t, Flatten(vecSeqs(l)))
+	})
+	t.Run("partial", func(t *testing.T) {
+		c := Chunk(From[int](0, 1).Take(250), 100)
+		lens := ToSlice(Map(c, (*Vec[int]).Len))
+		if len(lens) != 3 || lens[0] != 100 || lens[1] != 100 || lens[2] != 50 {
+			t.Fatalf("Expected chunk lengths [100 100 50], but got %v", lens)
+		}
+		if v, ok := c.Elem(2); !ok || v.Len() != 50 {
+			t.Fatalf("Expected c[2] to have 50 elements, but was %v", v)
+		}
+		if v, ok := c.Elem(3); ok {
+			t.Fatalf("Expected c[3] to return no value, but got %v", v)
+		}
+	})
+}
+
+func TestWindow(t *testing.T) {
+	check := func(t *testing.T, w Seq[*Vec[int]], size, step, count int) {
+		t.Helper()
+		var n int
+		w.Iterate(func(v *Vec[int]) bool {
+			if v.Len() != uint64(size) {
+				t.Fatalf("Expected window %d to have %d elements, but had %d", n, size, v.Len())
+			}
+			for i, e := range v.All() {
+				if e != n*step+int(i) {
+					t.Fatalf("Expected window %d [%d] == %d, but was %d", n, i, n*step+int(i), e)
+				}
+			}
+			if ev, ok := w.Elem(uint64(n)); !ok || ev.Len() != v.Len() {
+				t.Fatalf("Expected Elem(%d) to match the iterated window", n)
+			}
+			n++
+			return true
+		})
+		if n != count {
+			t.Fatalf("Expected %d windows, but got %d", count, n)
+		}
+	}
+
+	t.Run("overlap", func(t *testing.T) {
+		check(t, Window(From[int](0, 1).Take(1000), 100, 3), 100, 3, 301)
+	})
+	t.Run("skip", func(t *testing.T) {
+		check(t, Window(From[int](0, 1).Take(1000), 10, 15), 10, 15, 67)
+	})
+	t.Run("inf", func(t *testing.T) {
+		l, r := Window(From[int](0, 1), 70, 7).Split(50)
+		check(t, l, 70, 7, 50)
+		if v, ok := r.Elem(0); !ok {
+			t.Fatalf("Expected r[0] to return a value")
+		} else if e, _ := v.Elem(0); e != 350 {
+			t.Fatalf("Expected r[0][0] == 350, but was %d", e)
+		}
+	})
+	t.Run("persistent", func(t *testing.T) {
+		ws := ToSlice(Window(From[int](0, 1).Take(300), 130, 1))
+		for n, v := range ws {
+			if e, _ := v.Elem(129); e != n+129 {
+				t.Fatalf("Window %d was modified by later windows", n)
+			}
+		}
+	})
+}
+
+func TestPairwise(t *testing.T) {
+	p := Pairwise(From[int](0, 1))
+	var n int
+	p.Take(100).Iterate(func(e Pair[int, int]) bool {
+		if e.First != n || e.Second != n+1 {
+			t.Fatalf("Expected {%d %d}, but got %v", n, n+1, e)
+		}
+		n++
+		return true
+	})
+	if n != 100 {
+		t.Fatalf("Expected 100 pairs, but got %d", n)
+	}
+	if l := len(ToSlice(Pairwise(From[int](0, 1).Take(1)))); l != 0 {
+		t.Fatalf("Expected no pairs, but got %d", l)
+	}
+}
@@ -0,0 +1,133 @@
+package ion
+
+import (
+	"iter"
+)
+
+// Pair holds two values of types T and U.
+type Pair[T, U any] struct {
+	First  T
+	Second U
+}
+
+// MakePair returns a Pair containing `a` and `b`.
+func MakePair[T, U any](a T, b U) Pair[T, U] {
+	return Pair[T, U]{First: a, Second: b}
+}
+
+type zipSeq[T, U, V any] struct {
+	a Seq[T]
+	b Seq[U]
+	f func(T, U) V
+}
+
+func (z *zipSeq[T, U, V]) Elem(i uint64) (V, bool) {
+	ea, ok := z.a.Elem(i)
+	if !ok {
+		var r V
+		return r, false
+	}
+	eb, ok := z.b.Elem(i)
+	if !ok {
+		var r V
+		return r, false
+	}
+	return z.f(ea, eb), true
+}
+
+func (z *zipSeq[T, U, V]) Split(n uint64) (Seq[V], Seq[V]) {
+	al, ar := z.a.Split(n)
+	bl, br := z.b.Split(n)
+	l := &zipSeq[T, U, V]{
+		a: al,
+		b: bl,
+		f: z.f,
+	}
+	r := &zipSeq[T, U, V]{
+		a: ar,
+		b: br,
+		f: z.f,
+	}
+	return l, r
+}
+
+func (z *zipSeq[T, U, V]) Take(n uint64) Seq[V] {
+	return &zipSeq[T, U, V]{
+		a: z.a.Take(n),
+		b: z.b.Take(n),
+		f: z.f,
+	}
+}
+
+func (z *zipSeq[T, U, V]) Iterate(f func(V) bool) {
+	// We can only push from one of the sequences, so we pull from the other.
+	next, stop := iter.Pull(Values(z.b))
+	defer stop()
+	z.a.Iterate(func(ea T) bool {
+		eb, ok := next()
+		if !ok {
+			return false
+		}
+		return f(z.f(ea, eb))
+	})
+}
+
+func (z *zipSeq[T, U, V]) Lazy(f func(func() V) bool) {
+	next, stop := iter.Pull(func(yield func(func() U) bool) {
+		z.b.Lazy(yield)
+	})
+	defer stop()
+	z.a.Lazy(func(ea func() T) bool {
+		eb, ok := next()
+		if !ok {
+			return false
+		}
+		return f(func() V {
+			return z.f(ea(), eb())
+		})
+	})
+}
+
+// ZipWith takes two Seqs `a` and `b` and returns a Seq[V] whose elements are
+// the result of applying `f` to the elements of `a` and `b` at the same index.
+// The resulting Seq is as long as the shorter of `a` and `b`.
+//
+// Like Map, ZipWith is lazy, so it is safe to zip unbounded sequences, and
+// `f` may be called multiple times on the same elements.
+//
+// For example:
+//
+//	// 0, 2, 4, 6, ...
+//	evens := ZipWith(From[int](0, 1), From[int](0, 1), func(a, b int) int {
+//		return a + b
+//	})
+func ZipWith[T, U, V any](a Seq[T], b Seq[U], f func(T, U) V) Seq[V] {
+	return &zipSeq[T, U, V]{
+		a: a,
+		b: b,
+		f: f,
+	}
+}
+
+// Zip takes two Seqs `a` and `b` and returns a Seq of Pairs of the
+// elements of `a` and `b` at the same index. The resulting Seq is as long
+// as the shorter of `a` and `b`.
+//
+// Zip(a, b) is equivalent to
+//
+//	ZipWith(a, b, MakePair[T, U])
+func Zip[T, U any](a Seq[T], b Seq[U]) Seq[Pair[T, U]] {
+	return ZipWith(a, b, MakePair[T, U])
+}
+
+// Unzip takes a Seq of Pairs and returns two Seqs, the first containing
+// the first element of each Pair, and the second containing the second
+// element of each Pair.
+//
+// Both resulting Seqs realize elements of `s` independently. If realizing
+// elements of `s` is expensive or stateful, consider Memo'ing `s` first.
+func Unzip[T, U any](s Seq[Pair[T, U]]) (Seq[T], Seq[U]) {
+	a := Map(s, func(p Pair[T, U]) T { return p.First })
+	b := Map(s, func(p Pair[T, U]) U { return p.Second })
+	return a, b
+}
@@ -0,0 +1,71 @@
+package ion
+
+import (
+	"testing"
+)
+
+func TestZipWith(t *testing.T) {
+	add := func(a, b int) int { return a + b }
+	t.Run("inf", func(t *testing.T) {
+		testInfSeq(t, ZipWith(From[int](0, 2), From[int](0, -1), add))
+	})
+	t.Run("fin1", func(t *testing.T) {
+		testFinSeq(t, ZipWith(From[int](0, 2), From[int](0, -1).Take(10000), add))
+	})
+	t.Run("fin2", func(t *testing.T) {
+		testFinSeq(t, ZipWith(From[int](0, 2).Take(20000), From[int](0, -1), add).Take(10000))
+	})
+	t.Run("fin3", func(t *testing.T) {
+		var i int
+		g := StateGen(func() (int, bool) {
+			ret := i
+			i++
+			return ret, true
+		})
+		l, _ := ZipWith(g, Repeatedly(0), add).Split(10000)
+		testFinSeq(t, l)
+	})
+}
+
+func TestZip(t *testing.T) {
+	z := Zip(From[int](0, 1), Map(From[int](0, 1), func(i int) string {
+		return string(rune('a' + i))
+	}).Take(26))
+
+	if e, ok := z.Elem(2); !ok || e.First != 2 || e.Second != "c" {
+		t.Fatalf("Expected z[2] == {2 c}, but was %v", e)
+	}
+	if e, ok := z.Elem(26); ok {
+		t.Fatalf("Expected z[26] to return no value, but got %v", e)
+	}
+	if l := len(ToSlice(z)); l != 26 {
+		t.Fatalf("Expected 26 elements, but got %d", l)
+	}
+
+	_, r := z.Split(25)
+	if e, ok := r.Elem(0); !ok || e.First != 25 || e.Second != "z" {
+		t.Fatalf("Expected r[0] == {25 z}, but was %v", e)
+	}
+
+	var n int
+	z.Lazy(func(e func() Pair[int, string]) bool {
+		if p := e(); p.Second != string(rune('a'+p.First)) {
+			t.Fatalf("Unexpected pair %v", p)
+		}
+		n++
+		return true
+	})
+	if n != 26 {
+		t.Fatalf("Expected 26 elements, but got %d", n)
+	}
+}
+
+func TestUnzip(t *testing.T) {
+	a, b := Unzip(Zip(From[int](0, 1), From[int](0, 2)))
+	t.Run("first", func(t *testing.T) {
+		testInfSeq(t, a)
+	})
+	t.Run("second", func(t *testing.T) {
+		testInfSeq(t, Map(b, func(i int) int { return i / 2 }))
+	})
+}